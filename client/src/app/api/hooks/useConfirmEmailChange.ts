@@ -0,0 +1,37 @@
+import { useMutation, useQueryClient } from "@tanstack/react-query";
+import {
+  api,
+  ConfirmEmailChangeResponse,
+  VerifyEmailBodyType,
+} from "@app/api";
+import { MutationHookOptions } from "@app/types/utils";
+
+type ConfirmEmailChangeMutationOptions = MutationHookOptions<
+  ConfirmEmailChangeResponse["user"],
+  Error,
+  VerifyEmailBodyType
+>;
+
+export function useConfirmEmailChange(
+  options?: ConfirmEmailChangeMutationOptions,
+) {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    ...options,
+    mutationFn: async (data) => {
+      const json = await api.post<ConfirmEmailChangeResponse>(
+        "/users/me/email/confirm",
+        {
+          body: JSON.stringify(data),
+        },
+      );
+
+      return json.user;
+    },
+    onSuccess: (data, variables, context) => {
+      queryClient.setQueryData(["user"], data);
+      options?.onSuccess?.(data, variables, context);
+    },
+  });
+}
@@ -0,0 +1,42 @@
+import { useMutation } from "@tanstack/react-query";
+import {
+  api,
+  ForgotPasswordBodyType,
+  ResetPasswordBodyType,
+  SuccessMessageResponse,
+} from "@app/api";
+import { MutationHookOptions } from "@app/types/utils";
+
+type ForgotPasswordMutationOptions = MutationHookOptions<
+  SuccessMessageResponse,
+  Error,
+  ForgotPasswordBodyType
+>;
+
+type ResetPasswordMutationOptions = MutationHookOptions<
+  SuccessMessageResponse,
+  Error,
+  ResetPasswordBodyType
+>;
+
+export function useForgotPassword(options?: ForgotPasswordMutationOptions) {
+  return useMutation({
+    ...options,
+    mutationFn: async (data) => {
+      return await api.post<SuccessMessageResponse>("/auth/password/forgot", {
+        body: JSON.stringify(data),
+      });
+    },
+  });
+}
+
+export function useResetPassword(options?: ResetPasswordMutationOptions) {
+  return useMutation({
+    ...options,
+    mutationFn: async (data) => {
+      return await api.post<SuccessMessageResponse>("/auth/password/reset", {
+        body: JSON.stringify(data),
+      });
+    },
+  });
+}
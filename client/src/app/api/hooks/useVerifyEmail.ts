@@ -0,0 +1,22 @@
+import { useMutation } from "@tanstack/react-query";
+import { api, VerifyEmailBodyType, VerifyEmailResponse } from "@app/api";
+import { MutationHookOptions } from "@app/types/utils";
+
+type VerifyEmailMutationOptions = MutationHookOptions<
+  VerifyEmailResponse["user"],
+  Error,
+  VerifyEmailBodyType
+>;
+
+export function useVerifyEmail(options?: VerifyEmailMutationOptions) {
+  return useMutation({
+    ...options,
+    mutationFn: async (data) => {
+      const json = await api.post<VerifyEmailResponse>("/auth/verify", {
+        body: JSON.stringify(data),
+      });
+
+      return json.user;
+    },
+  });
+}
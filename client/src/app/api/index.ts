@@ -10,6 +10,9 @@ export * from "./queryKeys.ts";
 
 export * from "./hooks/useLogin";
 export * from "./hooks/useRegister";
+export * from "./hooks/useVerifyEmail";
+export * from "./hooks/usePasswordReset";
+export * from "./hooks/useConfirmEmailChange";
 export * from "./hooks/usePendingFriendRequests";
 export * from "./hooks/useLogout";
 export * from "./hooks/useChats";
@@ -12,6 +12,7 @@ export type LoginUserResponse = {
 
 export type RegisterUserBodyType = {
   username: string;
+  handle: string;
   email: string;
   password: string;
   confirmPassword: string;
@@ -27,3 +28,25 @@ export type GetLoggedInUserResponse = {
   accessToken: string;
   refreshToken: string;
 };
+
+export type VerifyEmailBodyType = {
+  token: string;
+};
+
+export type VerifyEmailResponse = {
+  user: UserResponse;
+};
+
+export type ForgotPasswordBodyType = {
+  email: string;
+};
+
+export type ResetPasswordBodyType = {
+  token: string;
+  password: string;
+  confirmPassword: string;
+};
+
+export type ConfirmEmailChangeResponse = {
+  user: UserResponse;
+};
@@ -1,4 +1,4 @@
-import { UserResponse } from "@app/api";
+import { PublicUser } from "@app/api";
 
 export enum ChatType {
   PRIVATE = "private",
@@ -11,7 +11,7 @@ export type Chat = {
   type: ChatType;
   createdAt: string;
   updatedAt: string;
-  members: Array<UserResponse>;
+  members: Array<PublicUser>;
 };
 
 export type GetAllChats = {
@@ -28,7 +28,7 @@ export type Message = {
   text: string;
   createdAt: string;
   updatedAt: string;
-  user: UserResponse;
+  user: PublicUser;
 };
 
 export type GetChat = {
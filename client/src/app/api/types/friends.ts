@@ -1,4 +1,4 @@
-import { UserResponse } from "@app/api";
+import { PublicUser } from "@app/api";
 
 export enum FriendRequestStatus {
   PENDING = "pending",
@@ -11,7 +11,7 @@ export type FriendRequest = {
   status: FriendRequestStatus;
   requestedAt: string;
   statusChangedAt: string;
-  user: UserResponse;
+  user: PublicUser;
 };
 
 export type PendingFriendsResponse = {
@@ -19,5 +19,5 @@ export type PendingFriendsResponse = {
 };
 
 export type AllFriendResponse = {
-  friends: Array<UserResponse>;
+  friends: Array<PublicUser>;
 };
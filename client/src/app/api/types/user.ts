@@ -1,6 +1,37 @@
+export type CustomStatus = {
+  text: string | null;
+  emoji: string | null;
+  expiresAt: string | null;
+  doNotDisturb: boolean;
+};
+
+export type Avatar = {
+  small: string;
+  medium: string;
+  large: string;
+};
+
+export type PublicUser = {
+  id: number;
+  username: string;
+  handle: string;
+  displayName: string | null;
+  bio: string | null;
+  avatar: Avatar | null;
+  bannerColor: string | null;
+  nickname?: string;
+  status?: "online" | "idle" | "offline";
+  customStatus?: CustomStatus;
+};
+
 export type UserResponse = {
   id: number;
   username: string;
+  handle: string;
+  displayName: string | null;
+  bio: string | null;
+  avatar: Avatar | null;
+  bannerColor: string | null;
   email: string;
   active: boolean;
   createdAt: string;
@@ -21,7 +21,13 @@ export const NewMessageWsSchema = z.object({
 
 export type NewMessageWsType = z.infer<typeof NewMessageWsSchema>;
 
-export const ChatNameUpdatedWsSchema = z.object({
-  type: z.literal(WsMessages.chatNameUpdated),
-  newName: z.string(),
+export const ChatUpdatedWsSchema = z.object({
+  type: z.literal(WsMessages.chatUpdated),
+  chatId: z.number(),
+  changes: z.object({
+    name: z.string().optional(),
+    description: z.string().optional(),
+    topic: z.string().optional(),
+    iconUrl: z.string().optional(),
+  }),
 });
@@ -1,5 +1,9 @@
 export enum WsMessages {
   updateAccessToken = "UPDATE_ACCESS_TOKEN",
   newMessage = "NEW_MESSAGE",
-  chatNameUpdated = "CHAT_NAME_UPDATED",
+  chatUpdated = "CHAT_UPDATED",
+  presenceUpdate = "PRESENCE_UPDATE",
+  setPresence = "SET_PRESENCE",
+  customStatusUpdate = "CUSTOM_STATUS_UPDATE",
+  userUpdated = "USER_UPDATED",
 }
@@ -1,4 +1,5 @@
 import { z } from "zod";
+import { WsMessages } from "@app/api/wstypes/messages.ts";
 
 export enum NotificationType {
   friendRequest = "friend_request",
@@ -34,3 +35,41 @@ export type NewMessageNotification = z.infer<
 export type FriendRequestNotification = z.infer<
   typeof FriendRequestNotificationSchema
 >;
+
+export const PresenceStatusSchema = z.enum(["online", "idle", "offline"]);
+
+export const PresenceUpdateWsSchema = z.object({
+  type: z.literal(WsMessages.presenceUpdate),
+  userId: z.number(),
+  status: PresenceStatusSchema,
+});
+
+export type PresenceStatus = z.infer<typeof PresenceStatusSchema>;
+
+export const CustomStatusUpdateWsSchema = z.object({
+  type: z.literal(WsMessages.customStatusUpdate),
+  userId: z.number(),
+  customStatus: z
+    .object({
+      text: z.string().nullable(),
+      emoji: z.string().nullable(),
+      expiresAt: z.string().nullable(),
+      doNotDisturb: z.boolean(),
+    })
+    .nullable(),
+});
+
+export const UserUpdatedWsSchema = z.object({
+  type: z.literal(WsMessages.userUpdated),
+  user: z.object({
+    id: z.number(),
+    username: z.string(),
+    handle: z.string(),
+    displayName: z.string().nullable(),
+    bio: z.string().nullable(),
+    avatar: z
+      .object({ small: z.string(), medium: z.string(), large: z.string() })
+      .nullable(),
+    bannerColor: z.string().nullable(),
+  }),
+});
@@ -2,7 +2,7 @@ import * as P from "@radix-ui/react-popover";
 import { cn } from "@app/utils/cn.ts";
 import DCButton from "@app/components/Button.tsx";
 import React from "react";
-import { UserResponse } from "@app/api";
+import { PublicUser } from "@app/api";
 import { Checkbox, CheckboxIndicator } from "@radix-ui/react-checkbox";
 import { CheckIcon } from "lucide-react";
 
@@ -47,7 +47,7 @@ export const UserSelectListTrigger = P.Trigger;
 export const UserSelectListRoot = P.Root;
 
 type UserSelectListProps = {
-  users: UserResponse[];
+  users: PublicUser[];
   onSubmit: (selectedIds: number[]) => void;
   submitLabel: string;
   selectedIds: number[];
@@ -4,7 +4,6 @@ import {
   GetAllChats,
   GetChat,
   QueryKeys,
-  SuccessMessageResponse,
 } from "@app/api";
 import React from "react";
 import { useChatId } from "@app/hooks/useChatId.ts";
@@ -36,13 +35,13 @@ function NameChangeElement({
   const queryClient = useQueryClient();
   const toast = useToast();
   const { mutate, isPending } = useMutation<
-    SuccessMessageResponse,
+    unknown,
     ClientError,
     NameChangeFormData
   >({
     mutationFn: async (data: NameChangeFormData) =>
-      api.put<SuccessMessageResponse>(`/chats/${chatId}/update-name`, {
-        body: JSON.stringify(data),
+      api.put<unknown>(`/chats/${chatId}`, {
+        body: JSON.stringify({ name: data.newName }),
       }),
     onMutate: async (inputData) => {
       updateChatName(inputData.newName);
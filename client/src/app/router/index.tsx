@@ -8,6 +8,10 @@ import FriendRequestsPage from "./pages/FriendRequests";
 import AllFriendsPage from "./pages/AllFriends";
 import InviteUserPage from "./pages/InviteUser";
 import PrivateChat from "./pages/PrivateChat";
+import VerifyEmailPage from "./pages/VerifyEmail";
+import ForgotPasswordPage from "./pages/ForgotPassword";
+import ResetPasswordPage from "./pages/ResetPassword";
+import ConfirmEmailPage from "./pages/ConfirmEmail";
 
 export const router = createBrowserRouter([
   {
@@ -50,6 +54,22 @@ export const router = createBrowserRouter([
         path: "register",
         element: <RegisterPage />,
       },
+      {
+        path: "verify-email",
+        element: <VerifyEmailPage />,
+      },
+      {
+        path: "forgot-password",
+        element: <ForgotPasswordPage />,
+      },
+      {
+        path: "reset-password",
+        element: <ResetPasswordPage />,
+      },
+      {
+        path: "confirm-email",
+        element: <ConfirmEmailPage />,
+      },
     ],
   },
 ]);
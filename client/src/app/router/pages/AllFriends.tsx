@@ -53,7 +53,9 @@ function FriendsList({
   onRetry: () => void;
 }) {
   const filteredFriends = friends?.filter((friend) =>
-    friend.username.toLowerCase().includes(search.toLowerCase()),
+    (friend.nickname ?? friend.username)
+      .toLowerCase()
+      .includes(search.toLowerCase()),
   );
 
   if (error) {
@@ -104,7 +106,7 @@ function FriendsList({
       {filteredFriends?.map((friend) => (
         <FriendListItem
           id={friend.id}
-          username={friend.username}
+          username={friend.nickname ?? friend.username}
           key={friend.id}
         />
       ))}
@@ -0,0 +1,39 @@
+import { useConfirmEmailChange } from "@app/api";
+import { useEffect } from "react";
+import { Link, useSearchParams } from "react-router-dom";
+
+export default function ConfirmEmailPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token");
+  const {
+    mutate: confirmEmailChange,
+    isSuccess,
+    isError,
+    error,
+  } = useConfirmEmailChange();
+
+  useEffect(() => {
+    if (token) {
+      confirmEmailChange({ token });
+    }
+  }, [token, confirmEmailChange]);
+
+  let message = "We are switching your email, give us a second";
+  if (!token || isError) {
+    message = error?.message ?? "Confirmation link is invalid";
+  } else if (isSuccess) {
+    message = "Your email was changed";
+  }
+
+  return (
+    <div className="h-screen flex items-center justify-center text-gray-50 bg-neutral-800">
+      <div className="bg-dc-neutral-900 p-4 rounded-md flex flex-col max-w-lg w-full shadow-sm text-center gap-2">
+        <h1 className="text-2xl font-semibold">Email change</h1>
+        <p className="text-dc-neutral-400">{message}</p>
+        <Link to="/home/friends" className="text-sky-500">
+          Go to the app
+        </Link>
+      </div>
+    </div>
+  );
+}
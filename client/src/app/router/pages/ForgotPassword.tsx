@@ -0,0 +1,86 @@
+import { useForgotPassword } from "@app/api";
+import { Link } from "react-router-dom";
+import { z } from "zod";
+import { Controller, useForm } from "react-hook-form";
+import { zodResolver } from "@hookform/resolvers/zod";
+import DCInput from "../../components/Input";
+import DCButton from "../../components/Button";
+
+const ForgotPasswordFormSchema = z.object({
+  email: z.string().email(),
+});
+
+type ForgotPasswordFormValues = z.infer<typeof ForgotPasswordFormSchema>;
+
+export default function ForgotPasswordPage() {
+  const form = useForm<ForgotPasswordFormValues>({
+    resolver: zodResolver(ForgotPasswordFormSchema),
+    defaultValues: {
+      email: "",
+    },
+  });
+
+  const {
+    mutate: forgotPassword,
+    isPending,
+    isSuccess,
+    data,
+  } = useForgotPassword({
+    onError: (err) => {
+      form.setError("email", {
+        message: err.message,
+      });
+    },
+  });
+
+  return (
+    <div className="h-screen flex items-center justify-center text-gray-50 bg-neutral-800">
+      <form
+        onSubmit={form.handleSubmit((values) => forgotPassword(values))}
+        className="bg-dc-neutral-900 p-4 rounded-md flex flex-col max-w-lg w-full shadow-sm"
+      >
+        <div className="pb-2">
+          <h1 className="text-2xl font-semibold text-center">
+            Forgot your password?
+          </h1>
+          <p className="text-dc-neutral-400 text-center">
+            {isSuccess
+              ? data.message
+              : "We will send you a link to set a new one"}
+          </p>
+        </div>
+        <div className="pb-6">
+          <Controller
+            control={form.control}
+            name="email"
+            disabled={isPending}
+            render={({ field, formState: { errors } }) => (
+              <DCInput
+                label="email"
+                type="text"
+                error={errors.email?.message}
+                {...field}
+              />
+            )}
+          />
+        </div>
+        <DCButton
+          size="lg"
+          fontSize="md"
+          fontWeight="semibold"
+          className="mb-6"
+          disabled={isPending}
+        >
+          Send reset link
+        </DCButton>
+
+        <p className="text-sm text-dc-neutral-400">
+          Remembered it?{" "}
+          <Link to="/login" className="text-sky-500">
+            Login
+          </Link>
+        </p>
+      </form>
+    </div>
+  );
+}
@@ -9,7 +9,9 @@ import { api, SuccessMessageResponse } from "@app/api";
 import { Container } from "@app/components/friends/FriendPageContainer";
 
 const InviteFormSchema = z.object({
-  email: z.string().email("Enter a valid email address to invite user"),
+  handle: z
+    .string()
+    .regex(/^@?[a-zA-Z0-9_.]{3,32}$/, "Enter a valid handle to invite user"),
 });
 
 type InviteFormValues = z.infer<typeof InviteFormSchema>;
@@ -18,7 +20,7 @@ export default function InviteUserPage() {
   const form = useForm<InviteFormValues>({
     mode: "onSubmit",
     defaultValues: {
-      email: "",
+      handle: "",
     },
     resolver: zodResolver(InviteFormSchema),
   });
@@ -32,12 +34,12 @@ export default function InviteUserPage() {
       }),
     onError: (err) => {
       if (err instanceof ClientError) {
-        form.setError("email", {
+        form.setError("handle", {
           message: err.message,
         });
       } else {
         console.error(err);
-        form.setError("email", {
+        form.setError("handle", {
           message: "Something went wrong, please try again later",
         });
       }
@@ -45,7 +47,7 @@ export default function InviteUserPage() {
     },
     onSuccess: () => {
       setShowSuccess(true);
-      form.clearErrors("email");
+      form.clearErrors("handle");
       form.reset();
     },
   });
@@ -59,24 +61,24 @@ export default function InviteUserPage() {
       <h1 className="uppercase tracking-wide font-semibold">Invite User</h1>
       <p className="text-sm text-dc-neutral-300">
         You can invite users to join Discord by sending them invite with their
-        email
+        handle
       </p>
       <form
         className={cn(
           "flex bg-dc-neutral-1000 py-2 px-3 items-center rounded-md focus-within:ring-2 ring-sky-500 cursor-text",
-          form.formState.errors.email && "ring-dc-red-500",
+          form.formState.errors.handle && "ring-dc-red-500",
           showSuccess && "ring-dc-green-500",
         )}
-        onClick={() => form.setFocus("email")}
+        onClick={() => form.setFocus("handle")}
         onSubmit={form.handleSubmit(sendFriendRequest)}
       >
         <input
           className="bg-transparent placeholder:text-dc-neutral-300 ring-0 flex-grow text-lg outline-none"
           type="text"
-          placeholder="Enter users email address"
-          {...form.register("email")}
+          placeholder="Enter users handle"
+          {...form.register("handle")}
           onChange={(e) => {
-            form.setValue("email", e.target.value);
+            form.setValue("handle", e.target.value);
             setShowSuccess(false);
           }}
         />
@@ -93,9 +95,9 @@ export default function InviteUserPage() {
           Friend invite request sent successfully
         </p>
       )}
-      {form.formState.errors.email && (
+      {form.formState.errors.handle && (
         <p className="text-sm text-dc-red-500">
-          {form.formState.errors.email.message}
+          {form.formState.errors.handle.message}
         </p>
       )}
     </Container>
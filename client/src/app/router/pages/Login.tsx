@@ -91,6 +91,11 @@ export default function LoginPage() {
           Login
         </DCButton>
 
+        <p className="text-sm text-dc-neutral-400 pb-2">
+          <Link to="/forgot-password" className="text-sky-500">
+            Forgot your password?
+          </Link>
+        </p>
         <p className="text-sm text-dc-neutral-400">
           Need an account?{" "}
           <Link to="/register" className="text-sky-500">
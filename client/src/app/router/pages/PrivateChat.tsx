@@ -15,7 +15,7 @@ import OneDayChatMessageGroup from "@app/components/chats/OneDayChatMessageGroup
 import { useChatId } from "@app/hooks/useChatId";
 import { useWebsocket } from "@app/api/hooks/useWebsocket";
 import {
-  ChatNameUpdatedWsSchema,
+  ChatUpdatedWsSchema,
   NewMessageWsSchema,
   NewMessageWsType,
 } from "@app/api/wstypes/chats";
@@ -142,9 +142,9 @@ export default function PrivateChat() {
       if (newMessageResult.success) {
         addNewMessageToChat(newMessageResult.data.message);
       }
-      const chatNameUpdateResult = ChatNameUpdatedWsSchema.safeParse(data);
-      if (chatNameUpdateResult.success) {
-        updateChatNameFromWs(chatNameUpdateResult.data.newName);
+      const chatUpdateResult = ChatUpdatedWsSchema.safeParse(data);
+      if (chatUpdateResult.success && chatUpdateResult.data.changes.name) {
+        updateChatNameFromWs(chatUpdateResult.data.changes.name);
       }
     },
     [addNewMessageToChat, updateChatNameFromWs],
@@ -10,6 +10,12 @@ const registerFormSchema = z
   .object({
     email: z.string().email(),
     username: z.string().min(3).max(32),
+    handle: z
+      .string()
+      .regex(
+        /^[a-zA-Z0-9_.]{3,32}$/,
+        "Handle can only contain letters, numbers, _ and .",
+      ),
     password: z
       .string()
       .regex(
@@ -34,6 +40,7 @@ export default function RegisterPage() {
     onError: (error) => {
       form.setError("email", { message: error.message });
       form.setError("username", { message: error.message });
+      form.setError("handle", { message: error.message });
       form.setError("password", { message: error.message });
       form.setError("confirmPassword", { message: error.message });
     },
@@ -84,6 +91,22 @@ export default function RegisterPage() {
             )}
           />
         </div>
+        <div className="flex flex-col pb-6">
+          <Controller
+            control={form.control}
+            name="handle"
+            disabled={isPending}
+            render={({ field, formState: { errors } }) => (
+              <DCInput
+                {...field}
+                error={errors.handle?.message}
+                type="text"
+                id="handle"
+                label="Handle"
+              />
+            )}
+          />
+        </div>
         <div className="pb-6">
           <Controller
             control={form.control}
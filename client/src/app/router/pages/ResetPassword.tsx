@@ -0,0 +1,106 @@
+import { useResetPassword } from "@app/api";
+import { Link, useNavigate, useSearchParams } from "react-router-dom";
+import { z } from "zod";
+import { Controller, useForm } from "react-hook-form";
+import { zodResolver } from "@hookform/resolvers/zod";
+import DCInput from "../../components/Input";
+import DCButton from "../../components/Button";
+
+const ResetPasswordFormSchema = z
+  .object({
+    password: z.string().min(8).max(24),
+    confirmPassword: z.string().min(8).max(24),
+  })
+  .refine((values) => values.password === values.confirmPassword, {
+    message: "Passwords do not match",
+    path: ["confirmPassword"],
+  });
+
+type ResetPasswordFormValues = z.infer<typeof ResetPasswordFormSchema>;
+
+export default function ResetPasswordPage() {
+  const navigate = useNavigate();
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token") ?? "";
+
+  const form = useForm<ResetPasswordFormValues>({
+    resolver: zodResolver(ResetPasswordFormSchema),
+    defaultValues: {
+      password: "",
+      confirmPassword: "",
+    },
+  });
+
+  const { mutate: resetPassword, isPending } = useResetPassword({
+    onSuccess: () => {
+      navigate("/login");
+    },
+    onError: (err) => {
+      form.setError("password", {
+        message: err.message,
+      });
+    },
+  });
+
+  return (
+    <div className="h-screen flex items-center justify-center text-gray-50 bg-neutral-800">
+      <form
+        onSubmit={form.handleSubmit((values) =>
+          resetPassword({ token, ...values }),
+        )}
+        className="bg-dc-neutral-900 p-4 rounded-md flex flex-col max-w-lg w-full shadow-sm"
+      >
+        <div className="pb-2">
+          <h1 className="text-2xl font-semibold text-center">
+            Set a new password
+          </h1>
+        </div>
+        <div className="pb-6">
+          <Controller
+            control={form.control}
+            name="password"
+            disabled={isPending}
+            render={({ field, formState: { errors } }) => (
+              <DCInput
+                label="password"
+                type="password"
+                error={errors.password?.message}
+                {...field}
+              />
+            )}
+          />
+        </div>
+        <div className="pb-6">
+          <Controller
+            control={form.control}
+            name="confirmPassword"
+            disabled={isPending}
+            render={({ field, formState: { errors } }) => (
+              <DCInput
+                label="confirm password"
+                type="password"
+                error={errors.confirmPassword?.message}
+                {...field}
+              />
+            )}
+          />
+        </div>
+        <DCButton
+          size="lg"
+          fontSize="md"
+          fontWeight="semibold"
+          className="mb-6"
+          disabled={isPending || !token}
+        >
+          Change password
+        </DCButton>
+
+        <p className="text-sm text-dc-neutral-400">
+          <Link to="/forgot-password" className="text-sky-500">
+            Send a new link
+          </Link>
+        </p>
+      </form>
+    </div>
+  );
+}
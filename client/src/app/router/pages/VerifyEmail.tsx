@@ -0,0 +1,34 @@
+import { useVerifyEmail } from "@app/api";
+import { useEffect } from "react";
+import { Link, useSearchParams } from "react-router-dom";
+
+export default function VerifyEmailPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token");
+  const { mutate: verifyEmail, isSuccess, isError, error } = useVerifyEmail();
+
+  useEffect(() => {
+    if (token) {
+      verifyEmail({ token });
+    }
+  }, [token, verifyEmail]);
+
+  let message = "We are verifying your email, give us a second";
+  if (!token || isError) {
+    message = error?.message ?? "Verification link is invalid";
+  } else if (isSuccess) {
+    message = "Your email is verified";
+  }
+
+  return (
+    <div className="h-screen flex items-center justify-center text-gray-50 bg-neutral-800">
+      <div className="bg-dc-neutral-900 p-4 rounded-md flex flex-col max-w-lg w-full shadow-sm text-center gap-2">
+        <h1 className="text-2xl font-semibold">Email verification</h1>
+        <p className="text-dc-neutral-400">{message}</p>
+        <Link to="/home/friends" className="text-sky-500">
+          Go to the app
+        </Link>
+      </div>
+    </div>
+  );
+}
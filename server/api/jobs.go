@@ -0,0 +1,41 @@
+package api
+
+import (
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/ws"
+	"log/slog"
+	"time"
+)
+
+const (
+	customStatusSweepInterval = 30 * time.Second
+	dataExportSweepInterval   = time.Hour
+)
+
+// runCustomStatusSweeper periodically clears expired custom statuses and tells the
+// affected users' audience that the status is gone.
+func runCustomStatusSweeper(userService store.UserServiceInterface, presenceService ws.PresenceServiceInterface) {
+	ticker := time.NewTicker(customStatusSweepInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		userIDs, err := userService.ClearExpiredCustomStatuses()
+		if err != nil {
+			slog.Error("could not clear expired custom statuses", "error", err)
+			continue
+		}
+		for _, userID := range userIDs {
+			presenceService.BroadcastCustomStatus(userID, nil)
+		}
+	}
+}
+
+// runDataExportSweeper periodically deletes exports whose archives are past their retention period.
+func runDataExportSweeper(dataExportService store.DataExportServiceInterface) {
+	ticker := time.NewTicker(dataExportSweepInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := dataExportService.DeleteExpiredExports(); err != nil {
+			slog.Error("could not delete expired data exports", "error", err)
+		}
+	}
+}
@@ -15,6 +15,10 @@ func setupRoutes(
 	mux *mux.Router,
 	authMiddleware middlewares.AuthMiddleware,
 	isChatMemberMiddleware middlewares.IsChatMemberMiddleware,
+	isChatAdminMiddleware middlewares.IsChatAdminMiddleware,
+	isServerMemberMiddleware middlewares.IsServerMemberMiddleware,
+	isServerAdminMiddleware middlewares.IsServerAdminMiddleware,
+	isVerifiedMiddleware middlewares.IsVerifiedMiddleware,
 	connectWsMiddleware middlewares.ConnectWsMiddleware,
 	wsAuthMiddleware middlewares.WsAuthMiddleware,
 	userService *store.UserService,
@@ -22,42 +26,120 @@ func setupRoutes(
 	chatService *store.ChatService,
 	messageService *store.MessageService,
 	notificationStore store.NotificationServiceInterface,
+	inviteService *store.InviteService,
+	serverService *store.ServerService,
+	auditLogService *store.AuditLogService,
+	blockService *store.BlockService,
+	passwordResetService store.PasswordResetServiceInterface,
+	dataExportService store.DataExportServiceInterface,
+	fileStorage store.FileStorageInterface,
+	mailer store.Mailer,
+	friendRequestPolicy store.FriendRequestPolicyInterface,
 	notificationsWsService *ws.NotificationService,
 	chatWsService ws.ChatServiceInterface,
+	voiceWsService ws.VoiceServiceInterface,
+	presenceService ws.PresenceServiceInterface,
+	slowModeLimiter *utils.RateLimiter,
+	userLookupLimiter *utils.RateLimiter,
+	verificationEmailLimiter *utils.RateLimiter,
+	passwordResetLimiter *utils.RateLimiter,
 	v *validator.Validate,
 ) {
 
 	mux.HandleFunc("/healthcheck", utils.HandlerFunc(handlers.HandleHealthcheck())).Methods(http.MethodGet)
+	mux.PathPrefix("/uploads/").Handler(fileStorage.Handler()).Methods(http.MethodGet)
 
-	mux.HandleFunc("/auth/register", utils.HandlerFunc(handlers.HandleRegisterUser(userService, v))).Methods(http.MethodPost)
+	mux.HandleFunc("/auth/register", utils.HandlerFunc(handlers.HandleRegisterUser(userService, mailer, v))).Methods(http.MethodPost)
+	mux.HandleFunc("/auth/verify", utils.HandlerFunc(handlers.HandleVerifyEmail(userService, v))).Methods(http.MethodPost)
+	mux.HandleFunc("/auth/password/forgot", utils.HandlerFunc(handlers.HandleForgotPassword(userService, passwordResetService, mailer, passwordResetLimiter, v))).Methods(http.MethodPost)
+	mux.HandleFunc("/auth/password/reset", utils.HandlerFunc(handlers.HandleResetPassword(passwordResetService, v))).Methods(http.MethodPost)
+	mux.HandleFunc("/auth/verify/resend", utils.HandlerFunc(authMiddleware(handlers.HandleResendVerificationEmail(userService, mailer, verificationEmailLimiter)))).Methods(http.MethodPost)
 	mux.HandleFunc("/auth/login", utils.HandlerFunc(handlers.HandleLogin(userService, v))).Methods(http.MethodPost)
 	mux.HandleFunc("/auth/me", utils.HandlerFunc(authMiddleware(handlers.HandleGetLoggedInUser()))).Methods(http.MethodGet)
 	mux.HandleFunc("/auth/logout", utils.HandlerFunc(handlers.HandleLogoutUser())).Methods(http.MethodPost)
 
-	mux.HandleFunc("/friends", utils.HandlerFunc(authMiddleware(handlers.HandleGetFriends(friendshipService)))).Methods(http.MethodGet)
-	mux.HandleFunc("/friends", utils.HandlerFunc(authMiddleware(handlers.HandleSendFriendRequest(userService, notificationStore, notificationsWsService, friendshipService, v)))).Methods(http.MethodPost)
+	mux.HandleFunc("/friends", utils.HandlerFunc(authMiddleware(handlers.HandleGetFriends(friendshipService, presenceService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/friends", utils.HandlerFunc(authMiddleware(isVerifiedMiddleware(handlers.HandleSendFriendRequest(userService, notificationStore, notificationsWsService, friendshipService, friendRequestPolicy, userLookupLimiter, v))))).Methods(http.MethodPost)
+	mux.HandleFunc("/friends/suggestions", utils.HandlerFunc(authMiddleware(handlers.HandleGetFriendSuggestions(friendshipService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/friends/{friendID}/profile", utils.HandlerFunc(authMiddleware(handlers.HandleGetFriendProfile(friendshipService, userService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/friends/{friendID}/profile", utils.HandlerFunc(authMiddleware(handlers.HandleUpdateFriendProfile(friendshipService, v)))).Methods(http.MethodPut)
 	mux.HandleFunc("/friends/{friendID}", utils.HandlerFunc(authMiddleware(handlers.HandleRemoveFriend(friendshipService)))).Methods(http.MethodDelete)
 	mux.HandleFunc("/friends/requests", utils.HandlerFunc(authMiddleware(handlers.HandleGetFriendRequests(friendshipService)))).Methods(http.MethodGet)
-	mux.HandleFunc("/friends/requests/{requestId}/accept", utils.HandlerFunc(authMiddleware(handlers.HandleAcceptFriendRequest(friendshipService)))).Methods(http.MethodPost)
+	mux.HandleFunc("/friends/requests/outgoing", utils.HandlerFunc(authMiddleware(handlers.HandleGetOutgoingFriendRequests(friendshipService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/friends/requests/{requestId}", utils.HandlerFunc(authMiddleware(handlers.HandleCancelFriendRequest(friendshipService, notificationStore, notificationsWsService)))).Methods(http.MethodDelete)
+	mux.HandleFunc("/friends/requests/{requestId}/accept", utils.HandlerFunc(authMiddleware(handlers.HandleAcceptFriendRequest(friendshipService, userService, notificationStore, notificationsWsService)))).Methods(http.MethodPost)
 	mux.HandleFunc("/friends/requests/{requestId}/reject", utils.HandlerFunc(authMiddleware(handlers.HandleRejectFriendRequest(friendshipService)))).Methods(http.MethodPost)
 
+	mux.HandleFunc("/users/{userID}/mutual-friends", utils.HandlerFunc(authMiddleware(handlers.HandleGetMutualFriends(friendshipService, blockService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/users/lookup", utils.HandlerFunc(authMiddleware(handlers.HandleLookupUser(userService, blockService, userLookupLimiter)))).Methods(http.MethodGet)
+	mux.HandleFunc("/users/me", utils.HandlerFunc(authMiddleware(handlers.HandleUpdateProfile(userService, presenceService, v)))).Methods(http.MethodPatch)
+	mux.HandleFunc("/users/me", utils.HandlerFunc(authMiddleware(handlers.HandleDeleteAccount(userService, fileStorage, dataExportService, notificationsWsService, chatWsService, v)))).Methods(http.MethodDelete)
+	mux.HandleFunc("/users/me/export", utils.HandlerFunc(authMiddleware(handlers.HandleCreateDataExport(dataExportService)))).Methods(http.MethodPost)
+	mux.HandleFunc("/users/me/export/{exportID:[0-9]+}", utils.HandlerFunc(authMiddleware(handlers.HandleDownloadDataExport(dataExportService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/users/me/password", utils.HandlerFunc(authMiddleware(handlers.HandleChangePassword(userService, v)))).Methods(http.MethodPut)
+	mux.HandleFunc("/users/me/email", utils.HandlerFunc(authMiddleware(handlers.HandleChangeEmail(userService, mailer, v)))).Methods(http.MethodPut)
+	mux.HandleFunc("/users/me/email/confirm", utils.HandlerFunc(authMiddleware(handlers.HandleConfirmEmailChange(userService, v)))).Methods(http.MethodPost)
+	mux.HandleFunc("/users/me/avatar", utils.HandlerFunc(authMiddleware(handlers.HandleUploadAvatar(userService, presenceService, fileStorage)))).Methods(http.MethodPut)
+	mux.HandleFunc("/users/me/avatar", utils.HandlerFunc(authMiddleware(handlers.HandleDeleteAvatar(userService, presenceService, fileStorage)))).Methods(http.MethodDelete)
+	mux.HandleFunc("/users/me/privacy", utils.HandlerFunc(authMiddleware(handlers.HandleGetPrivacySettings(userService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/users/me/privacy", utils.HandlerFunc(authMiddleware(handlers.HandleUpdatePrivacySettings(userService, v)))).Methods(http.MethodPatch)
+	mux.HandleFunc("/users/me/status", utils.HandlerFunc(authMiddleware(handlers.HandleGetCustomStatus(userService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/users/me/status", utils.HandlerFunc(authMiddleware(handlers.HandleUpdateCustomStatus(userService, presenceService, v)))).Methods(http.MethodPut)
+	mux.HandleFunc("/users/me/blocks", utils.HandlerFunc(authMiddleware(handlers.HandleGetBlockedUsers(blockService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/users/{userID:[0-9]+}", utils.HandlerFunc(authMiddleware(handlers.HandleGetUser(userService, blockService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/users/{userID}/block", utils.HandlerFunc(authMiddleware(handlers.HandleBlockUser(blockService, friendshipService, userService)))).Methods(http.MethodPost)
+	mux.HandleFunc("/users/{userID}/block", utils.HandlerFunc(authMiddleware(handlers.HandleUnblockUser(blockService)))).Methods(http.MethodDelete)
+
 	mux.HandleFunc("/chats", utils.HandlerFunc(authMiddleware(handlers.HandleGetUsersChats(chatService)))).Methods(http.MethodGet)
-	mux.HandleFunc("/chats/private", utils.HandlerFunc(authMiddleware(handlers.HandleCreatePrivateChat(chatService, friendshipService, v)))).Methods(http.MethodPost)
+	mux.HandleFunc("/chats/private", utils.HandlerFunc(authMiddleware(handlers.HandleCreatePrivateChat(chatService, friendshipService, blockService, v)))).Methods(http.MethodPost)
 	mux.HandleFunc("/chats/group", utils.HandlerFunc(authMiddleware(handlers.HandleCreateGroupChat(chatService, userService, v)))).Methods(http.MethodPost)
-	mux.HandleFunc("/chats/{chatID}/messages", utils.HandlerFunc(authMiddleware(handlers.HandleSendMessage(chatService, messageService, chatWsService, notificationStore, notificationsWsService, v)))).Methods(http.MethodPost)
+	mux.HandleFunc("/chats/{chatID}/messages", utils.HandlerFunc(authMiddleware(handlers.HandleSendMessage(chatService, messageService, chatWsService, notificationStore, notificationsWsService, blockService, slowModeLimiter, v)))).Methods(http.MethodPost)
 	mux.HandleFunc("/chats/{chatID}", utils.HandlerFunc(authMiddleware(handlers.HandleGetChatWithMessages(chatService)))).Methods(http.MethodGet)
-	mux.HandleFunc("/chats/{chatID}/update-name", utils.HandlerFunc(authMiddleware(handlers.HandleUpdateChatName(chatService, chatWsService, v)))).Methods(http.MethodPut)
-	mux.HandleFunc("/chats/{chatID}/members/add", utils.HandlerFunc(authMiddleware(isChatMemberMiddleware(handlers.HandleAddUsersToChat(chatService, v))))).Methods(http.MethodPost)
+	mux.HandleFunc("/chats/{chatID}", utils.HandlerFunc(authMiddleware(handlers.HandleDeleteChat(chatService, chatWsService, voiceWsService)))).Methods(http.MethodDelete)
+	mux.HandleFunc("/chats/{chatID}/transfer-ownership", utils.HandlerFunc(authMiddleware(handlers.HandleTransferChatOwnership(chatService, v)))).Methods(http.MethodPost)
+	mux.HandleFunc("/chats/{chatID}", utils.HandlerFunc(authMiddleware(handlers.HandleUpdateChat(chatService, chatWsService, v)))).Methods(http.MethodPut)
+	mux.HandleFunc("/chats/{chatID}/icon", utils.HandlerFunc(authMiddleware(handlers.HandleUploadChatIcon(chatService, chatWsService, fileStorage)))).Methods(http.MethodPut)
+	mux.HandleFunc("/chats/{chatID}/icon", utils.HandlerFunc(authMiddleware(handlers.HandleDeleteChatIcon(chatService, chatWsService, fileStorage)))).Methods(http.MethodDelete)
+	mux.HandleFunc("/chats/{chatID}/settings", utils.HandlerFunc(authMiddleware(isChatMemberMiddleware(handlers.HandleUpdateChatSettings(chatService, v))))).Methods(http.MethodPut)
+	mux.HandleFunc("/chats/{chatID}/slow-mode", utils.HandlerFunc(authMiddleware(isChatAdminMiddleware(handlers.HandleUpdateChatSlowMode(chatService, v))))).Methods(http.MethodPut)
+	mux.HandleFunc("/chats/{chatID}/members/{userID}/role", utils.HandlerFunc(authMiddleware(isChatAdminMiddleware(handlers.HandleUpdateChatMemberRole(chatService, v))))).Methods(http.MethodPut)
+	mux.HandleFunc("/chats/{chatID}/members/add", utils.HandlerFunc(authMiddleware(isChatAdminMiddleware(handlers.HandleAddUsersToChat(chatService, userService, v))))).Methods(http.MethodPost)
+	mux.HandleFunc("/chats/{chatID}/members/me", utils.HandlerFunc(authMiddleware(isChatMemberMiddleware(handlers.HandleLeaveChat(chatService, chatWsService, voiceWsService))))).Methods(http.MethodDelete)
+	mux.HandleFunc("/chats/{chatID}/members/{userID}", utils.HandlerFunc(authMiddleware(isChatMemberMiddleware(handlers.HandleKickChatMember(chatService, chatWsService, voiceWsService))))).Methods(http.MethodDelete)
+	mux.HandleFunc("/chats/{chatID}/audit-log", utils.HandlerFunc(authMiddleware(isChatAdminMiddleware(handlers.HandleGetChatAuditLog(auditLogService))))).Methods(http.MethodGet)
+
+	mux.HandleFunc("/chats/{chatID}/invites", utils.HandlerFunc(authMiddleware(isChatMemberMiddleware(handlers.HandleCreateChatInvite(chatService, inviteService, v))))).Methods(http.MethodPost)
+	mux.HandleFunc("/chats/{chatID}/invites", utils.HandlerFunc(authMiddleware(isChatAdminMiddleware(handlers.HandleGetChatInvites(inviteService))))).Methods(http.MethodGet)
+	mux.HandleFunc("/chats/{chatID}/invites/{code}", utils.HandlerFunc(authMiddleware(isChatAdminMiddleware(handlers.HandleRevokeChatInvite(inviteService))))).Methods(http.MethodDelete)
+	mux.HandleFunc("/chats/{chatID}/invites/{code}/uses", utils.HandlerFunc(authMiddleware(isChatAdminMiddleware(handlers.HandleGetChatInviteUses(inviteService))))).Methods(http.MethodGet)
+
+	mux.HandleFunc("/invites/{code}", utils.HandlerFunc(authMiddleware(handlers.HandleGetInvitePreview(inviteService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/invites/{code}/accept", utils.HandlerFunc(authMiddleware(handlers.HandleAcceptInvite(inviteService)))).Methods(http.MethodPost)
+
+	mux.HandleFunc("/servers", utils.HandlerFunc(authMiddleware(handlers.HandleGetUsersServers(serverService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/servers", utils.HandlerFunc(authMiddleware(handlers.HandleCreateServer(serverService, v)))).Methods(http.MethodPost)
+	mux.HandleFunc("/servers/{serverID}", utils.HandlerFunc(authMiddleware(isServerMemberMiddleware(handlers.HandleGetServer(serverService))))).Methods(http.MethodGet)
+	mux.HandleFunc("/servers/{serverID}", utils.HandlerFunc(authMiddleware(isServerAdminMiddleware(handlers.HandleUpdateServer(serverService, v))))).Methods(http.MethodPut)
+	mux.HandleFunc("/servers/{serverID}", utils.HandlerFunc(authMiddleware(isServerMemberMiddleware(handlers.HandleDeleteServer(serverService, chatWsService, voiceWsService))))).Methods(http.MethodDelete)
+	mux.HandleFunc("/servers/{serverID}/members", utils.HandlerFunc(authMiddleware(isServerMemberMiddleware(handlers.HandleGetServerMembers(serverService))))).Methods(http.MethodGet)
+	mux.HandleFunc("/servers/{serverID}/members", utils.HandlerFunc(authMiddleware(isServerAdminMiddleware(handlers.HandleAddServerMembers(serverService, userService, v))))).Methods(http.MethodPost)
+	mux.HandleFunc("/servers/{serverID}/members/me", utils.HandlerFunc(authMiddleware(isServerMemberMiddleware(handlers.HandleLeaveServer(serverService, chatWsService, voiceWsService))))).Methods(http.MethodDelete)
+	mux.HandleFunc("/servers/{serverID}/channels", utils.HandlerFunc(authMiddleware(isServerAdminMiddleware(handlers.HandleCreateChannel(serverService, v))))).Methods(http.MethodPost)
+	mux.HandleFunc("/servers/{serverID}/channels/{chatID}", utils.HandlerFunc(authMiddleware(isServerAdminMiddleware(handlers.HandleUpdateChannel(serverService, chatService, chatWsService, v))))).Methods(http.MethodPut)
+	mux.HandleFunc("/servers/{serverID}/channels/{chatID}", utils.HandlerFunc(authMiddleware(isServerAdminMiddleware(handlers.HandleDeleteChannel(serverService, chatWsService, voiceWsService))))).Methods(http.MethodDelete)
 
-	mux.HandleFunc("/ws/chats/{chatID}", utils.WsHandler(wsAuthMiddleware(handlers.HandleConnectToChat(chatWsService)))).Methods(http.MethodGet)
+	mux.HandleFunc("/ws/chats/{chatID}", utils.WsHandler(wsAuthMiddleware(isChatMemberMiddleware(handlers.HandleConnectToChat(chatWsService))))).Methods(http.MethodGet)
+	mux.HandleFunc("/ws/voice/{chatID}", utils.WsHandler(wsAuthMiddleware(isChatMemberMiddleware(handlers.HandleConnectToVoice(voiceWsService))))).Methods(http.MethodGet)
 
 	mux.HandleFunc(
 		"/ws/notifications",
-		utils.WsHandler(wsAuthMiddleware(handlers.HandleSubscribeNotifications(notificationsWsService))),
+		utils.WsHandler(wsAuthMiddleware(handlers.HandleSubscribeNotifications(notificationsWsService, presenceService))),
 	).Methods(http.MethodGet)
 
 	mux.HandleFunc("/notifications/friend-requests/mark-as-seen", utils.HandlerFunc(authMiddleware(handlers.HandleMarkFriendRequestNotificationsAsSeen(notificationStore)))).Methods(http.MethodPut)
 	mux.HandleFunc("/notifications/new-messages/mark-as-seen", utils.HandlerFunc(authMiddleware(handlers.HandleMarkNewMessageNotificationsAsSeen(notificationStore, chatService, v)))).Methods(http.MethodPut)
 	mux.HandleFunc("/notifications/new-messages", utils.HandlerFunc(authMiddleware(handlers.HandleGetNewMessageNotifications(notificationStore)))).Methods(http.MethodGet)
 	mux.HandleFunc("/notifications/friend-requests", utils.HandlerFunc(authMiddleware(handlers.HandleGetFriendRequestNotifications(notificationStore)))).Methods(http.MethodGet)
+	mux.HandleFunc("/notifications/friend-requests-accepted/mark-as-seen", utils.HandlerFunc(authMiddleware(handlers.HandleMarkFriendRequestAcceptedNotificationsAsSeen(notificationStore)))).Methods(http.MethodPut)
+	mux.HandleFunc("/notifications/friend-requests-accepted", utils.HandlerFunc(authMiddleware(handlers.HandleGetFriendRequestAcceptedNotifications(notificationStore)))).Methods(http.MethodGet)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/kacperhemperek/discord-go/middlewares"
 	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/utils"
 	"github.com/kacperhemperek/discord-go/ws"
 	"github.com/rs/cors"
 	"net/http"
@@ -25,6 +26,9 @@ func (s *Server) Start() error {
 	store.RunMigrations(db)
 
 	v := validator.New()
+	if err := v.RegisterValidation("handle", utils.ValidateHandle); err != nil {
+		return err
+	}
 
 	// register all store services
 	notificationStore := store.NewNotificationService(db, v)
@@ -32,21 +36,51 @@ func (s *Server) Start() error {
 	friendshipService := store.NewFriendshipService(db)
 	chatService := store.NewChatService(db)
 	messageService := store.NewMessageService(db)
+	inviteService := store.NewInviteService(db)
+	serverService := store.NewServerService(db)
+	auditLogService := store.NewAuditLogService(db)
+	blockService := store.NewBlockService(db)
+	passwordResetService := store.NewPasswordResetService(db)
+	dataExportService := store.NewDataExportService(db)
+	fileStorage := store.NewFileStorage()
+	mailer := store.NewLogMailer()
+	friendRequestPolicy := store.NewFriendRequestPolicy(db, blockService, userService)
+	lastSeenTracker := store.NewLastSeenTracker(db)
+
+	if err := dataExportService.FailInterruptedExports(); err != nil {
+		return err
+	}
 
 	// register all ws services
-	notificationsWsService := ws.NewNotificationService()
-	chatWsService := ws.NewChatService()
+	userConnCounter := ws.NewUserConnCounter(lastSeenTracker)
+	notificationsWsService := ws.NewNotificationService(userConnCounter)
+	chatWsService := ws.NewChatService(userConnCounter)
+	voiceWsService := ws.NewVoiceService()
+	presenceService := ws.NewPresenceService(notificationsWsService, userService.GetRelatedUserIDs)
+
+	slowModeLimiter := utils.NewRateLimiter()
+	userLookupLimiter := utils.NewRateLimiter()
+	verificationEmailLimiter := utils.NewRateLimiter()
+	passwordResetLimiter := utils.NewRateLimiter()
 
 	// register all middlewares
-	authMiddleware := middlewares.NewAuthMiddleware()
+	authMiddleware := middlewares.NewAuthMiddleware(lastSeenTracker, userService)
 	connectWsMiddleware := middlewares.NewConnectWsMiddleware()
-	wsAuthMiddleware := middlewares.NewWsAuthMiddleware()
+	wsAuthMiddleware := middlewares.NewWsAuthMiddleware(userService)
 	isChatMemberMiddleware := middlewares.NewIsChatMemberMiddleware(chatService)
+	isChatAdminMiddleware := middlewares.NewIsChatAdminMiddleware(chatService)
+	isServerMemberMiddleware := middlewares.NewIsServerMemberMiddleware(serverService)
+	isServerAdminMiddleware := middlewares.NewIsServerAdminMiddleware(serverService)
+	isVerifiedMiddleware := middlewares.NewIsVerifiedMiddleware(userService)
 
 	setupRoutes(
 		router,
 		authMiddleware,
 		isChatMemberMiddleware,
+		isChatAdminMiddleware,
+		isServerMemberMiddleware,
+		isServerAdminMiddleware,
+		isVerifiedMiddleware,
 		connectWsMiddleware,
 		wsAuthMiddleware,
 		userService,
@@ -54,11 +88,29 @@ func (s *Server) Start() error {
 		chatService,
 		messageService,
 		notificationStore,
+		inviteService,
+		serverService,
+		auditLogService,
+		blockService,
+		passwordResetService,
+		dataExportService,
+		fileStorage,
+		mailer,
+		friendRequestPolicy,
 		notificationsWsService,
 		chatWsService,
+		voiceWsService,
+		presenceService,
+		slowModeLimiter,
+		userLookupLimiter,
+		verificationEmailLimiter,
+		passwordResetLimiter,
 		v,
 	)
 
+	go runCustomStatusSweeper(userService, presenceService)
+	go runDataExportSweeper(dataExportService)
+
 	portStr := fmt.Sprintf(":%d", s.port)
 	fmt.Printf("Server is running on port %d\n", s.port)
 
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/types"
+	"github.com/kacperhemperek/discord-go/utils"
+	"net/http"
+	"strconv"
+)
+
+const maxAuditLogLimit = 100
+
+var auditLogLimitTooLargeErr = fmt.Errorf("limit can't be larger than %d", maxAuditLogLimit)
+
+func HandleGetChatAuditLog(auditLogService store.AuditLogServiceInterface) utils.APIHandler {
+	type response struct {
+		Entries    []*models.ChatAuditLogEntry `json:"entries"`
+		NextCursor *int                        `json:"nextCursor"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		query := r.URL.Query()
+		filters := &store.ChatAuditLogFilters{}
+		if actionParam := query.Get("action"); actionParam != "" {
+			action, err := types.ParseChatAuditAction(actionParam)
+			if err != nil {
+				return utils.NewInvalidQueryParamError("action", actionParam, err)
+			}
+			filters.Action = &action
+		}
+		if actorParam := query.Get("actorId"); actorParam != "" {
+			actorID, err := strconv.Atoi(actorParam)
+			if err != nil {
+				return utils.NewInvalidQueryParamError("actorId", actorParam, err)
+			}
+			filters.ActorID = &actorID
+		}
+		if beforeParam := query.Get("before"); beforeParam != "" {
+			beforeID, err := strconv.Atoi(beforeParam)
+			if err != nil {
+				return utils.NewInvalidQueryParamError("before", beforeParam, err)
+			}
+			filters.BeforeID = &beforeID
+		}
+		limitParam := query.Get("limit")
+		limit, err := store.NewLimitFilter(limitParam)
+		if err != nil {
+			return utils.NewInvalidQueryParamError("limit", limitParam, err)
+		}
+		if limit != nil && *limit < 1 {
+			return utils.NewInvalidQueryParamError("limit", limitParam, store.LimitNumberTooSmallErr)
+		}
+		if limit != nil && *limit > maxAuditLogLimit {
+			return utils.NewInvalidQueryParamError("limit", limitParam, auditLogLimitTooLargeErr)
+		}
+		filters.Limit = limit
+
+		entries, err := auditLogService.GetChatAuditLog(chatID, filters)
+		if err != nil {
+			return err
+		}
+		pageSize := store.DefaultAuditLogLimit
+		if limit != nil {
+			pageSize = *limit
+		}
+		var nextCursor *int
+		if len(entries) == pageSize {
+			nextCursor = &entries[len(entries)-1].ID
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Entries: entries, NextCursor: nextCursor})
+	}
+}
@@ -2,14 +2,28 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"github.com/go-playground/validator/v10"
+	"github.com/kacperhemperek/discord-go/models"
 	"github.com/kacperhemperek/discord-go/store"
 	"github.com/kacperhemperek/discord-go/utils"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// verificationEmailInterval is how often a user can ask for another verification email.
+const verificationEmailInterval = time.Minute
+
+// passwordResetEmailInterval is how often a reset email can be sent to a single address.
+const passwordResetEmailInterval = time.Minute
+
 type RegisterUserRequest struct {
 	Username        string `json:"username" validate:"required,max=24,min=2"`
+	Handle          string `json:"handle" validate:"required,handle"`
 	Password        string `json:"password" validate:"required,max=24,min=8"`
 	ConfirmPassword string `json:"confirmPassword" validate:"required,max=24,min=8"`
 	Email           string `json:"email" validate:"required,email"`
@@ -20,7 +34,7 @@ type LoginUserRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
-func HandleRegisterUser(userService *store.UserService, validate *validator.Validate) utils.APIHandler {
+func HandleRegisterUser(userService *store.UserService, mailer store.Mailer, validate *validator.Validate) utils.APIHandler {
 
 	return func(w http.ResponseWriter, r *http.Request, _ *utils.APIContext) error {
 		body := &RegisterUserRequest{}
@@ -29,6 +43,8 @@ func HandleRegisterUser(userService *store.UserService, validate *validator.Vali
 			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
 		}
 
+		body.Handle = utils.NormalizeHandle(body.Handle)
+
 		if err := validate.Struct(body); err != nil {
 			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
 		}
@@ -49,6 +65,16 @@ func HandleRegisterUser(userService *store.UserService, validate *validator.Vali
 			return &utils.APIError{Code: http.StatusConflict, Message: "User with this email already exists", Cause: nil}
 		}
 
+		_, err = userService.FindUserByHandle(body.Handle)
+
+		if err == nil {
+			return &utils.APIError{Code: http.StatusConflict, Message: "This handle is already taken", Cause: nil}
+		}
+
+		if !errors.Is(err, store.UserNotFoundError) {
+			return &utils.APIError{Code: http.StatusInternalServerError, Message: "Unknown error when finding user", Cause: err}
+		}
+
 		hashedPassword, err := utils.EncryptPassword(body.Password)
 
 		if err != nil {
@@ -57,12 +83,21 @@ func HandleRegisterUser(userService *store.UserService, validate *validator.Vali
 
 		body.Password = hashedPassword
 
-		user, err := userService.CreateUser(body.Username, body.Password, body.Email)
+		user, err := userService.CreateUser(body.Username, body.Handle, body.Password, body.Email)
+
+		if errors.Is(err, store.HandleTakenError) {
+			return &utils.APIError{Code: http.StatusConflict, Message: "This handle is already taken", Cause: err}
+		}
 
 		if err != nil {
 			return &utils.APIError{Code: http.StatusInternalServerError, Message: "Unknown error when creating user", Cause: err}
 		}
 
+		// the account is already created, the user can ask for another email if this one fails
+		if err := sendVerificationEmail(mailer, user.ID, user.Email); err != nil {
+			slog.Error("could not send verification email", "userID", user.ID, "error", err)
+		}
+
 		accessToken, accessTokenError := utils.NewAccessToken(&utils.NewTokenProps{
 			ID:        user.ID,
 			Email:     user.Email,
@@ -130,32 +165,16 @@ func HandleLogin(userService *store.UserService, validate *validator.Validate) u
 			return InvalidUserOrPasswordApiError
 		}
 
-		accessToken, err := utils.NewAccessToken(&utils.NewTokenProps{
-			ID:        user.ID,
-			Email:     user.Email,
-			Username:  user.Username,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		})
+		sessionVersion, err := userService.GetSessionVersion(user.ID)
 
 		if err != nil {
 			return err
 		}
 
-		refreshToken, err := utils.NewRefreshToken(&utils.NewTokenProps{
-			ID:        user.ID,
-			Email:     user.Email,
-			Username:  user.Username,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		})
-
-		if err != nil {
+		if err := setAuthTokens(w, user, sessionVersion); err != nil {
 			return err
 		}
 
-		utils.SetAuthCookies(w, accessToken, refreshToken)
-
 		return utils.WriteJson(
 			w,
 			http.StatusOK,
@@ -201,3 +220,204 @@ func HandleLogoutUser() utils.APIHandler {
 		return utils.WriteJson(w, http.StatusOK, &utils.JSON{"message": "user successfully logged out"})
 	}
 }
+
+// HandleVerifyEmail activates the account from the token sent in the verification email,
+// it doesn't need the user to be logged in since the link can be opened anywhere.
+func HandleVerifyEmail(userService store.UserServiceInterface, v *validator.Validate) utils.APIHandler {
+	type request struct {
+		Token string `json:"token" validate:"required"`
+	}
+	type response struct {
+		User *models.User `json:"user"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, _ *utils.APIContext) error {
+		invalidTokenApiError := &utils.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Verification link is invalid or expired",
+		}
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		claims, err := utils.ParseEmailVerificationToken(body.Token)
+		if err != nil {
+			invalidTokenApiError.Cause = err
+			return invalidTokenApiError
+		}
+		// the email was changed since the token was sent
+		user, err := userService.VerifyEmail(claims.UserID, claims.Email)
+		if errors.Is(err, store.UserNotFoundError) {
+			return invalidTokenApiError
+		}
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{User: user})
+	}
+}
+
+func HandleResendVerificationEmail(
+	userService store.UserServiceInterface,
+	mailer store.Mailer,
+	limiter *utils.RateLimiter,
+) utils.APIHandler {
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		user, err := userService.GetUserByID(c.User.ID)
+		if err != nil {
+			return err
+		}
+		if user.Active {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Email is already verified"}
+		}
+		allowed, retryAfter := limiter.Allow(strconv.Itoa(user.ID), verificationEmailInterval)
+		if !allowed {
+			return utils.WriteTooManyRequests(w, "Verification email was sent recently", retryAfter)
+		}
+		if err := sendVerificationEmail(mailer, user.ID, user.Email); err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &utils.JSON{
+			"message": "verification email sent",
+		})
+	}
+}
+
+func sendVerificationEmail(mailer store.Mailer, userID int, email string) error {
+	token, err := utils.NewEmailVerificationToken(userID, email)
+	if err != nil {
+		return err
+	}
+	link := utils.ClientURL("/verify-email?token=" + url.QueryEscape(token))
+	return mailer.Send(&store.Email{
+		To:      email,
+		Subject: "Verify your email",
+		Body:    fmt.Sprintf("Open the link below to verify your email, it expires in 24 hours.\n\n%s", link),
+	})
+}
+
+// HandleForgotPassword mails a password reset link to the user, it responds the same way whether
+// the email belongs to an account or not so it can't be used to find out who is registered.
+func HandleForgotPassword(
+	userService store.UserServiceInterface,
+	passwordResetService store.PasswordResetServiceInterface,
+	mailer store.Mailer,
+	limiter *utils.RateLimiter,
+	v *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, _ *utils.APIContext) error {
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		okResponse := &utils.JSON{
+			"message": "if an account with this email exists, a password reset link was sent to it",
+		}
+		// limited per address so nobody's inbox can be flooded, the response stays the same
+		if allowed, _ := limiter.Allow(strings.ToLower(body.Email), passwordResetEmailInterval); !allowed {
+			return utils.WriteJson(w, http.StatusOK, okResponse)
+		}
+		// the account is looked up and mailed after responding, so neither the response time nor
+		// an error can tell whether the email is registered
+		go sendPasswordResetEmail(userService, passwordResetService, mailer, body.Email)
+		return utils.WriteJson(w, http.StatusOK, okResponse)
+	}
+}
+
+func sendPasswordResetEmail(
+	userService store.UserServiceInterface,
+	passwordResetService store.PasswordResetServiceInterface,
+	mailer store.Mailer,
+	email string,
+) {
+	user, err := userService.FindUserByEmail(email)
+	if errors.Is(err, store.UserNotFoundError) {
+		return
+	}
+	if err != nil {
+		slog.Error("could not find user requesting password reset", "error", err)
+		return
+	}
+	token, err := passwordResetService.CreateResetToken(user.ID)
+	if err != nil {
+		slog.Error("could not create password reset token", "userID", user.ID, "error", err)
+		return
+	}
+	link := utils.ClientURL("/reset-password?token=" + url.QueryEscape(token))
+	err = mailer.Send(&store.Email{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Open the link below to set a new password, it expires in an hour.\n\n%s\n\nIf you didn't ask for it, you can ignore this email.",
+			link,
+		),
+	})
+	if err != nil {
+		slog.Error("could not send password reset email", "userID", user.ID, "error", err)
+	}
+}
+
+// HandleResetPassword sets a new password using the token from the reset email and logs the user
+// out everywhere by revoking their refresh tokens.
+func HandleResetPassword(
+	passwordResetService store.PasswordResetServiceInterface,
+	v *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		Token           string `json:"token" validate:"required"`
+		Password        string `json:"password" validate:"required,max=24,min=8"`
+		ConfirmPassword string `json:"confirmPassword" validate:"required,max=24,min=8"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, _ *utils.APIContext) error {
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		if body.Password != body.ConfirmPassword {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Passwords do not match"}
+		}
+		hashedPassword, err := utils.EncryptPassword(body.Password)
+		if err != nil {
+			return err
+		}
+		err = passwordResetService.ResetPassword(body.Token, hashedPassword)
+		if errors.Is(err, store.InvalidResetTokenErr) {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Password reset link is invalid or expired", Cause: err}
+		}
+		if err != nil {
+			return err
+		}
+		utils.RemoveAuthTokensCookies(w)
+		return utils.WriteJson(w, http.StatusOK, &utils.JSON{
+			"message": "password was changed, log in with the new password",
+		})
+	}
+}
+
+// setAuthTokens issues a new access and refresh token for the user and sets them as cookies,
+// the tokens carry the session version so they survive revoking the user's other sessions.
+func setAuthTokens(w http.ResponseWriter, user *models.User, sessionVersion int) error {
+	props := &utils.NewTokenProps{
+		ID:             user.ID,
+		Email:          user.Email,
+		Username:       user.Username,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+		SessionVersion: sessionVersion,
+	}
+	accessToken, err := utils.NewAccessToken(props)
+	if err != nil {
+		return err
+	}
+	refreshToken, err := utils.NewRefreshToken(props)
+	if err != nil {
+		return err
+	}
+	utils.SetAuthCookies(w, accessToken, refreshToken)
+	return nil
+}
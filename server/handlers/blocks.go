@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/utils"
+	"net/http"
+)
+
+func HandleBlockUser(
+	blockService store.BlockServiceInterface,
+	friendshipService store.FriendshipServiceInterface,
+	userService store.UserServiceInterface,
+) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		userID, err := utils.GetIntParam(r, "userID")
+		if err != nil {
+			return err
+		}
+		if userID == c.User.ID {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "You cannot block yourself",
+			}
+		}
+		users, err := userService.GetUsersByIDs([]int{userID})
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return utils.NewNotFoundError("user", "id", userID)
+		}
+		if err := blockService.BlockUser(c.User.ID, userID); err != nil {
+			return err
+		}
+		friendship, err := friendshipService.GetFriendshipByUsers(c.User.ID, userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if friendship != nil {
+			if err := friendshipService.DeleteFriendship(friendship.ID); err != nil {
+				return err
+			}
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "User blocked successfully"})
+	}
+}
+
+func HandleUnblockUser(blockService store.BlockServiceInterface) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		userID, err := utils.GetIntParam(r, "userID")
+		if err != nil {
+			return err
+		}
+		if err := blockService.UnblockUser(c.User.ID, userID); err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "User unblocked successfully"})
+	}
+}
+
+func HandleGetBlockedUsers(blockService store.BlockServiceInterface) utils.APIHandler {
+	type response struct {
+		Users []*models.PublicUser `json:"users"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		users, err := blockService.GetBlockedUsers(c.User.ID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Users: users})
+	}
+}
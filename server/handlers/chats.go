@@ -3,6 +3,7 @@ package handlers
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/kacperhemperek/discord-go/models"
 	"github.com/kacperhemperek/discord-go/store"
@@ -13,6 +14,7 @@ import (
 	"net/http"
 	"slices"
 	"strings"
+	"time"
 )
 
 type CreateChatRequestBody struct {
@@ -22,6 +24,7 @@ type CreateChatRequestBody struct {
 func HandleCreatePrivateChat(
 	chatService store.ChatServiceInterface,
 	friendService store.FriendshipServiceInterface,
+	blockService store.BlockServiceInterface,
 	validate *validator.Validate,
 ) utils.APIHandler {
 	type response struct {
@@ -43,6 +46,16 @@ func HandleCreatePrivateChat(
 				Message: "Cannot create private chat with yourself",
 			}
 		}
+		blocked, err := blockService.IsBlockedEitherWay(c.User.ID, body.UserID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return &utils.APIError{
+				Code:    http.StatusForbidden,
+				Message: "You cannot message this user",
+			}
+		}
 		chat, err := chatService.GetPrivateChatByUserIDs(c.User.ID, body.UserID)
 		if err != nil && !errors.Is(err, sql.ErrNoRows) {
 			return err
@@ -75,9 +88,13 @@ func HandleCreatePrivateChat(
 	}
 }
 
-func HandleAddUsersToChat(chatsStore store.ChatServiceInterface, v *validator.Validate) utils.APIHandler {
+func HandleAddUsersToChat(
+	chatService store.ChatServiceInterface,
+	userService store.UserServiceInterface,
+	v *validator.Validate,
+) utils.APIHandler {
 	type request struct {
-		UserIDs []int `json:"userIds" validation:"min=1,unique,dive,number,min=1"`
+		UserIDs []int `json:"userIds" validate:"min=1,max=50,unique,dive,min=1"`
 	}
 
 	type response struct {
@@ -85,12 +102,164 @@ func HandleAddUsersToChat(chatsStore store.ChatServiceInterface, v *validator.Va
 	}
 
 	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
 		body := &request{}
 		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
+			}
+		}
+		chat, err := chatService.GetChatByID(chatID)
+		if err != nil {
+			return err
+		}
+		if !chat.Type.Is(types.GroupChat) {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Users can only be added to group chats",
+			}
+		}
+		users, err := userService.GetUsersByIDs(body.UserIDs)
+		if err != nil {
+			return err
+		}
+		if len(users) != len(body.UserIDs) {
+			return &utils.APIError{
+				Message: "Not every user exists from provided list",
+				Code:    http.StatusNotFound,
+			}
+		}
+		addedIDs, err := chatService.AddChatMembers(chatID, c.User.ID, body.UserIDs)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{UserIDs: addedIDs})
+	}
+}
+
+func HandleKickChatMember(
+	chatService store.ChatServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	voiceWsService ws.VoiceServiceInterface,
+) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		userID, err := utils.GetIntParam(r, "userID")
+		if err != nil {
+			return err
+		}
+		if userID == c.User.ID {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "You cannot kick yourself",
+			}
+		}
+		chat, err := chatService.GetChatByID(chatID)
+		if err != nil {
+			return err
+		}
+		if !chat.Type.Is(types.GroupChat) {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Members can only be kicked from group chats",
+			}
+		}
+		targetRole, err := chatService.GetChatMemberRole(chatID, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return utils.NewNotFoundError("chat member", "id", userID)
+			}
+			return err
+		}
+		actorRole, err := chatService.GetChatMemberRole(chatID, c.User.ID)
+		if err != nil {
+			return err
+		}
+		if !actorRole.IsModerator() || !actorRole.Outranks(targetRole) {
+			return &utils.APIError{
+				Code:    http.StatusForbidden,
+				Message: "You can only kick members with a lower role than yours",
+			}
+		}
+		err = chatService.RemoveChatMember(chatID, c.User.ID, userID, &store.ChatAuditLogInput{
+			ChatID:       chatID,
+			ActorID:      c.User.ID,
+			Action:       types.MemberKickedAuditAction,
+			TargetUserID: &userID,
+			Before:       map[string]string{"role": targetRole.String()},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.NewNotFoundError("chat member", "id", userID)
+		}
+		if err != nil {
 			return err
 		}
+		if err := chatWsService.CloseUserConns(chatID, userID); err != nil {
+			slog.Error("could not close kicked member connections", "chatID", chatID, "userID", userID, "error", err)
+		}
+		voiceWsService.RemoveUser(chatID, userID)
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Member kicked successfully"})
+	}
+}
+
+func HandleLeaveChat(
+	chatService store.ChatServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	voiceWsService ws.VoiceServiceInterface,
+) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
 
-		return utils.WriteJson(w, http.StatusOK, &response{UserIDs: body.UserIDs})
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		chat, err := chatService.GetChatByID(chatID)
+		if err != nil {
+			return err
+		}
+		if !chat.Type.Is(types.GroupChat) {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "You can only leave group chats",
+			}
+		}
+		role, err := chatService.GetChatMemberRole(chatID, c.User.ID)
+		if err != nil {
+			return err
+		}
+		if role.Is(types.OwnerChatRole) {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Transfer ownership of the chat before leaving it",
+			}
+		}
+		err = chatService.RemoveChatMember(chatID, c.User.ID, c.User.ID, nil)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.NewNotFoundError("chat member", "id", c.User.ID)
+		}
+		if err != nil {
+			return err
+		}
+		if err := chatWsService.CloseUserConns(chatID, c.User.ID); err != nil {
+			slog.Error("could not close connections of user leaving chat", "chatID", chatID, "userID", c.User.ID, "error", err)
+		}
+		voiceWsService.RemoveUser(chatID, c.User.ID)
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Chat left successfully"})
 	}
 }
 
@@ -139,7 +308,7 @@ func HandleCreateGroupChat(
 			usernames[i] = user.Username
 		}
 		chatName := strings.Join(usernames, ", ")
-		chat, err := chatService.CreateGroupChat(chatName, allIDs)
+		chat, err := chatService.CreateGroupChat(chatName, c.User.ID, allIDs)
 		if err != nil {
 			return err
 		}
@@ -155,7 +324,15 @@ func HandleGetUsersChats(
 	}
 
 	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
-		chats, err := chatService.GetUsersChatsWithMembers(c.User.ID)
+		includeArchived := false
+		if archivedParam := r.URL.Query().Get("archived"); archivedParam != "" {
+			archived, err := store.NewBoolFilter(archivedParam)
+			if err != nil {
+				return utils.NewInvalidQueryParamError("archived", archivedParam, err)
+			}
+			includeArchived = *archived == "true"
+		}
+		chats, err := chatService.GetUsersChatsWithMembers(c.User.ID, includeArchived)
 		if err != nil {
 			return err
 		}
@@ -182,6 +359,8 @@ func HandleSendMessage(
 	chatWsService ws.ChatServiceInterface,
 	notificationStore store.NotificationServiceInterface,
 	notificationService ws.NotificationServiceInterface,
+	blockService store.BlockServiceInterface,
+	slowModeLimiter *utils.RateLimiter,
 	validate *validator.Validate,
 ) utils.APIHandler {
 	type response struct {
@@ -208,9 +387,30 @@ func HandleSendMessage(
 			}
 			return err
 		}
+		role, err := chatService.GetChatMemberRole(chat.ID, c.User.ID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return &utils.APIError{
+					Code:    http.StatusForbidden,
+					Message: "User is not a chat member",
+				}
+			}
+			return err
+		}
+		slowModeKey := fmt.Sprintf("%d:%d", chat.ID, c.User.ID)
+		slowMode := chat.SlowModeSeconds > 0 && !role.IsModerator()
+		if slowMode {
+			allowed, retryAfter := slowModeLimiter.Allow(slowModeKey, time.Duration(chat.SlowModeSeconds)*time.Second)
+			if !allowed {
+				return utils.WriteTooManyRequests(w, "Slow mode is enabled in this chat", retryAfter)
+			}
+		}
 		m, err := messageService.CreateMessageInChat(chat.ID, c.User.ID, body.Text)
 		if err != nil {
-			return nil
+			if slowMode {
+				slowModeLimiter.Release(slowModeKey)
+			}
+			return err
 		}
 		mwu, err := messageService.EnrichMessageWithUser(m)
 		if err != nil {
@@ -220,7 +420,11 @@ func HandleSendMessage(
 		if err != nil {
 			return err
 		}
-		err = chatWsService.BroadcastNewMessage(chatID, mwu)
+		blockerIDs, err := blockService.GetBlockerIDs(c.User.ID)
+		if err != nil {
+			return err
+		}
+		err = chatWsService.BroadcastNewMessage(chatID, mwu, blockerIDs)
 		if err != nil {
 			return err
 		}
@@ -231,7 +435,14 @@ func HandleSendMessage(
 			return err
 		}
 
-		members, err := chatService.GetChatMembersExcluding(chatID, activeMemberIDs)
+		mutedMemberIDs, err := chatService.GetMutedMemberIDs(chatID)
+
+		if err != nil {
+			return err
+		}
+
+		excludedIDs := slices.Concat(activeMemberIDs, mutedMemberIDs, blockerIDs)
+		members, err := chatService.GetChatMembersExcluding(chatID, excludedIDs)
 
 		if err != nil {
 			return err
@@ -277,12 +488,12 @@ func HandleGetChatWithMessages(
 		if err != nil {
 			return err
 		}
-		cwm, err := chatService.EnrichChatWithMessages(chat)
+		cwm, err := chatService.EnrichChatWithMessages(chat, c.User.ID)
 		if err != nil {
 			return err
 		}
 		if cwm.Type.Is(types.PrivateChat) {
-			members, err := chatService.GetChatMembers(chat.ID)
+			members, err := chatService.GetChatMembersWithNicknames(chat.ID, c.User.ID)
 			if err != nil {
 				return err
 			}
@@ -313,13 +524,21 @@ func HandleConnectToChat(wsChatService ws.ChatServiceInterface) utils.APIHandler
 	}
 }
 
-func HandleUpdateChatName(chatService store.ChatServiceInterface, chatWsService ws.ChatServiceInterface, validate *validator.Validate) utils.APIHandler {
+const maxChatIconSize = 2 << 20
+
+func HandleUpdateChat(
+	chatService store.ChatServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	validate *validator.Validate,
+) utils.APIHandler {
 	type request struct {
-		NewName string `json:"newName" validate:"min=6,max=32"`
+		Name        *string `json:"name" validate:"omitempty,min=6,max=32"`
+		Description *string `json:"description" validate:"omitempty,max=1024"`
+		Topic       *string `json:"topic" validate:"omitempty,max=256"`
 	}
 
 	type response struct {
-		Message string `json:"message"`
+		Chat *models.Chat `json:"chat"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
@@ -331,51 +550,507 @@ func HandleUpdateChatName(chatService store.ChatServiceInterface, chatWsService
 		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
 			return &utils.APIError{
 				Code:    http.StatusBadRequest,
-				Message: "Request body is on valid",
+				Message: "Request body is not valid",
+				Cause:   err,
 			}
 		}
-		chat, err := chatService.GetChatByID(chatID)
+		if body.Name == nil && body.Description == nil && body.Topic == nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Nothing to update",
+			}
+		}
+		oldChat, err := getEditableChat(chatService, chatID, c.User.ID)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				return utils.NewNotFoundError("chat", "id", chatID)
+			return err
+		}
+		changes := &models.ChatUpdate{
+			Name:        body.Name,
+			Description: body.Description,
+			Topic:       body.Topic,
+		}
+		chat, err := chatService.UpdateChat(chatID, c.User.ID, changes, chatUpdatedAuditEntry(c.User.ID, oldChat, changes))
+		if err != nil {
+			return err
+		}
+		err = chatWsService.BroadcastChatUpdated(chatID, changes)
+		if err != nil && !errors.Is(err, ws.ChatNotFoundErr) {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Chat: chat})
+	}
+}
+
+func HandleUploadChatIcon(
+	chatService store.ChatServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	fileStorage store.FileStorageInterface,
+) utils.APIHandler {
+	type response struct {
+		Chat *models.Chat `json:"chat"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		oldChat, err := getEditableChat(chatService, chatID, c.User.ID)
+		if err != nil {
+			return err
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxChatIconSize)
+		file, _, err := r.FormFile("icon")
+		if err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Icon has to be an image sent in the icon field and can't be larger than 2MB",
+				Cause:   err,
+			}
+		}
+		defer file.Close()
+		iconURL, err := fileStorage.SaveImage("chat-icons", file)
+		if err != nil {
+			if errors.Is(err, store.UnsupportedFileTypeErr) {
+				return &utils.APIError{
+					Code:    http.StatusBadRequest,
+					Message: "Icon has to be a png, jpeg, gif or webp image",
+					Cause:   err,
+				}
+			}
+			return err
+		}
+		changes := &models.ChatUpdate{IconURL: &iconURL}
+		chat, err := chatService.UpdateChat(chatID, c.User.ID, changes, chatUpdatedAuditEntry(c.User.ID, oldChat, changes))
+		if err != nil {
+			_ = fileStorage.Delete(iconURL)
+			return err
+		}
+		deleteOldChatIcon(fileStorage, oldChat)
+		err = chatWsService.BroadcastChatUpdated(chatID, changes)
+		if err != nil && !errors.Is(err, ws.ChatNotFoundErr) {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Chat: chat})
+	}
+}
+
+func HandleDeleteChatIcon(
+	chatService store.ChatServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	fileStorage store.FileStorageInterface,
+) utils.APIHandler {
+	type response struct {
+		Chat *models.Chat `json:"chat"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		oldChat, err := getEditableChat(chatService, chatID, c.User.ID)
+		if err != nil {
+			return err
+		}
+		noIcon := ""
+		changes := &models.ChatUpdate{IconURL: &noIcon}
+		chat, err := chatService.UpdateChat(chatID, c.User.ID, changes, chatUpdatedAuditEntry(c.User.ID, oldChat, changes))
+		if err != nil {
+			return err
+		}
+		deleteOldChatIcon(fileStorage, oldChat)
+		err = chatWsService.BroadcastChatUpdated(chatID, changes)
+		if err != nil && !errors.Is(err, ws.ChatNotFoundErr) {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Chat: chat})
+	}
+}
+
+// getEditableChat returns the chat when its details can be changed by the user,
+// private chats have no details of their own and channels are managed by server admins.
+func getEditableChat(chatService store.ChatServiceInterface, chatID, userID int) (*models.Chat, error) {
+	chat, err := chatService.GetChatByID(chatID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewNotFoundError("chat", "id", chatID)
+		}
+		return nil, err
+	}
+	if chat.Type.Is(types.PrivateChat) {
+		return nil, &utils.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "You cannot change details of private chat",
+		}
+	}
+	if chat.Type.Is(types.ChannelChat) {
+		return nil, &utils.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Channel details can only be changed by server admins",
+		}
+	}
+	if _, err := chatService.GetChatMemberRole(chatID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &utils.APIError{
+				Code:    http.StatusForbidden,
+				Message: "User can't update chat he is not a part of",
+			}
+		}
+		return nil, err
+	}
+	return chat, nil
+}
+
+// chatUpdatedAuditEntry describes which chat details changed, before only holds the previous values of the changed fields.
+func chatUpdatedAuditEntry(actorID int, oldChat *models.Chat, changes *models.ChatUpdate) *store.ChatAuditLogInput {
+	before := &models.ChatUpdate{}
+	if changes.Name != nil {
+		before.Name = &oldChat.Name
+	}
+	if changes.Description != nil {
+		before.Description = valueOrEmpty(oldChat.Description)
+	}
+	if changes.Topic != nil {
+		before.Topic = valueOrEmpty(oldChat.Topic)
+	}
+	if changes.IconURL != nil {
+		before.IconURL = valueOrEmpty(oldChat.IconURL)
+	}
+	return &store.ChatAuditLogInput{
+		ChatID:  oldChat.ID,
+		ActorID: actorID,
+		Action:  types.ChatUpdatedAuditAction,
+		Before:  before,
+		After:   changes,
+	}
+}
+
+func valueOrEmpty(value *string) *string {
+	if value == nil {
+		empty := ""
+		return &empty
+	}
+	return value
+}
+
+func deleteOldChatIcon(fileStorage store.FileStorageInterface, chat *models.Chat) {
+	if chat.IconURL == nil {
+		return
+	}
+	if err := fileStorage.Delete(*chat.IconURL); err != nil {
+		slog.Error("could not delete old chat icon", "chatID", chat.ID, "error", err)
+	}
+}
+
+func HandleUpdateChatSettings(chatService store.ChatServiceInterface, validate *validator.Validate) utils.APIHandler {
+	type request struct {
+		MutedUntil *time.Time `json:"mutedUntil"`
+		Archived   bool       `json:"archived"`
+		Favourite  bool       `json:"favourite"`
+	}
+
+	type response struct {
+		Settings *models.ChatSettings `json:"settings"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
+			}
+		}
+		settings := &models.ChatSettings{
+			Archived:  body.Archived,
+			Favourite: body.Favourite,
+		}
+		if body.MutedUntil != nil {
+			settings.MutedUntil = models.NullTime{Time: body.MutedUntil.UTC(), Valid: true}
+		}
+		updated, err := chatService.UpdateChatSettings(chatID, c.User.ID, settings)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Settings: updated})
+	}
+}
+
+func HandleUpdateChatSlowMode(
+	chatService store.ChatServiceInterface,
+	validate *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		Seconds int `json:"seconds" validate:"min=0,max=21600"`
+	}
 
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
 			}
+		}
+		chat, err := chatService.GetChatByID(chatID)
+		if err != nil {
 			return err
 		}
 		if chat.Type.Is(types.PrivateChat) {
 			return &utils.APIError{
 				Code:    http.StatusBadRequest,
-				Message: "You cannot change name of private chat",
+				Message: "Slow mode is not available in private chats",
 			}
 		}
-		users, err := chatService.GetChatMembers(chat.ID)
-		userIsMember := slices.ContainsFunc(users, func(user *models.User) bool {
-			return user.ID == c.User.ID
+		err = chatService.UpdateChatSlowMode(chatID, body.Seconds, &store.ChatAuditLogInput{
+			ChatID:  chatID,
+			ActorID: c.User.ID,
+			Action:  types.SlowModeUpdatedAuditAction,
+			Before:  map[string]int{"slowModeSeconds": chat.SlowModeSeconds},
+			After:   map[string]int{"slowModeSeconds": body.Seconds},
 		})
-		if !userIsMember {
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Slow mode updated successfully"})
+	}
+}
+
+func HandleUpdateChatMemberRole(
+	chatService store.ChatServiceInterface,
+	validate *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		Role types.ChatRole `json:"role"`
+	}
+
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		userID, err := utils.GetIntParam(r, "userID")
+		if err != nil {
+			return err
+		}
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
+			}
+		}
+		if body.Role.Is(types.OwnerChatRole) {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Ownership can only be transferred by the owner",
+			}
+		}
+		if userID == c.User.ID {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "You cannot change your own role",
+			}
+		}
+		chat, err := chatService.GetChatByID(chatID)
+		if err != nil {
+			return err
+		}
+		if !chat.Type.Is(types.GroupChat) {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Member roles can only be changed in group chats",
+			}
+		}
+		currentRole, err := chatService.GetChatMemberRole(chatID, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return utils.NewNotFoundError("chat member", "id", userID)
+			}
+			return err
+		}
+		if currentRole.Is(types.OwnerChatRole) {
+			return &utils.APIError{
+				Code:    http.StatusForbidden,
+				Message: "You cannot change the role of the chat owner",
+			}
+		}
+		actorRole, err := chatService.GetChatMemberRole(chatID, c.User.ID)
+		if err != nil {
+			return err
+		}
+		// admins manage moderators, only the owner can appoint or demote other admins
+		if (body.Role.Is(types.AdminChatRole) || currentRole.Is(types.AdminChatRole)) && !actorRole.Is(types.OwnerChatRole) {
 			return &utils.APIError{
 				Code:    http.StatusForbidden,
-				Message: "User can't update name of chat he is not a part of",
+				Message: "Only the chat owner can manage admins",
+			}
+		}
+		err = chatService.UpdateChatMemberRole(chatID, userID, body.Role, &store.ChatAuditLogInput{
+			ChatID:       chatID,
+			ActorID:      c.User.ID,
+			Action:       types.MemberRoleUpdatedAuditAction,
+			TargetUserID: &userID,
+			Before:       map[string]string{"role": currentRole.String()},
+			After:        map[string]string{"role": body.Role.String()},
+		})
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Member role updated successfully"})
+	}
+}
+
+func HandleDeleteChat(
+	chatService store.ChatServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	voiceWsService ws.VoiceServiceInterface,
+) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		if err := checkIsGroupChatOwner(chatService, chatID, c.User.ID); err != nil {
+			return err
+		}
+		if err := chatService.DeleteChat(chatID); err != nil {
+			return err
+		}
+		closeDeletedChat(chatWsService, voiceWsService, chatID)
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Chat deleted successfully"})
+	}
+}
+
+// closeDeletedChat tells the chat and voice connections of a deleted chat that it's gone and closes them.
+func closeDeletedChat(chatWsService ws.ChatServiceInterface, voiceWsService ws.VoiceServiceInterface, chatID int) {
+	err := chatWsService.CloseChat(chatID)
+	if err != nil && !errors.Is(err, ws.ChatNotFoundErr) {
+		slog.Error("could not close deleted chat connections", "chatID", chatID, "error", err)
+	}
+	voiceWsService.CloseRoom(chatID)
+}
+
+func HandleTransferChatOwnership(
+	chatService store.ChatServiceInterface,
+	validate *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		UserID int `json:"userId" validate:"required,min=1"`
+	}
+
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
 			}
 		}
-		err = chatService.UpdateChatName(chatID, body.NewName)
+		if body.UserID == c.User.ID {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "You already own this chat",
+			}
+		}
+		if err := checkIsGroupChatOwner(chatService, chatID, c.User.ID); err != nil {
+			return err
+		}
+		_, err = chatService.GetChatMemberRole(chatID, body.UserID)
 		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return utils.NewNotFoundError("chat member", "id", body.UserID)
+			}
 			return err
 		}
-		err = chatWsService.BroadcastNewChatName(chatID, body.NewName)
+		err = chatService.TransferChatOwnership(chatID, c.User.ID, body.UserID, &store.ChatAuditLogInput{
+			ChatID:       chatID,
+			ActorID:      c.User.ID,
+			Action:       types.OwnershipTransferredAuditAction,
+			TargetUserID: &body.UserID,
+			Before:       map[string]int{"ownerId": c.User.ID},
+			After:        map[string]int{"ownerId": body.UserID},
+		})
 		if err != nil {
 			return err
 		}
-		return utils.WriteJson(w, http.StatusOK, &response{Message: "Chat name updated successfully"})
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Chat ownership transferred successfully"})
 	}
 }
 
-func getPrivChatName(loggedInUserID int, members []*models.User) (string, error) {
+// checkIsGroupChatOwner returns an api error unless the chat is a group chat owned by the user.
+func checkIsGroupChatOwner(chatService store.ChatServiceInterface, chatID, userID int) error {
+	chat, err := chatService.GetChatByID(chatID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.NewNotFoundError("chat", "id", chatID)
+		}
+		return err
+	}
+	if !chat.Type.Is(types.GroupChat) {
+		return &utils.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Only group chats have an owner",
+		}
+	}
+	role, err := chatService.GetChatMemberRole(chatID, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err != nil || !role.Is(types.OwnerChatRole) {
+		return &utils.APIError{
+			Code:    http.StatusForbidden,
+			Message: "Only the chat owner can do that",
+		}
+	}
+	return nil
+}
+
+// getPrivChatName names the DM after the other member, using the nickname the logged in user gave them when there is one.
+func getPrivChatName(loggedInUserID int, members []*models.PublicUser) (string, error) {
 	var chatName string
 	found := false
 	for _, m := range members {
 		if m.ID != loggedInUserID {
 			chatName = m.Username
+			if m.Nickname != nil {
+				chatName = *m.Nickname
+			} else if m.DisplayName != nil {
+				chatName = *m.DisplayName
+			}
 			found = true
 			break
 		}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/types"
+	"github.com/kacperhemperek/discord-go/utils"
+	"net/http"
+)
+
+// HandleCreateDataExport starts building an archive with everything stored about the user,
+// clients poll the download endpoint until it's ready.
+func HandleCreateDataExport(dataExportService store.DataExportServiceInterface) utils.APIHandler {
+	type response struct {
+		Export *models.DataExport `json:"export"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		export, err := dataExportService.CreateExport(c.User.ID)
+		if errors.Is(err, store.DataExportInProgressErr) {
+			return &utils.APIError{
+				Code:    http.StatusConflict,
+				Message: "Your previous export is still being prepared",
+				Cause:   err,
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusAccepted, &response{Export: export})
+	}
+}
+
+func HandleDownloadDataExport(dataExportService store.DataExportServiceInterface) utils.APIHandler {
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		exportID, err := utils.GetIntParam(r, "exportID")
+		if err != nil {
+			return err
+		}
+		export, err := dataExportService.GetExport(exportID, c.User.ID)
+		if errors.Is(err, store.DataExportNotFoundErr) {
+			return utils.NewNotFoundError("Export", "id", exportID)
+		}
+		if err != nil {
+			return err
+		}
+		if export.Status.Is(types.PendingDataExport) {
+			return &utils.APIError{Code: http.StatusConflict, Message: "Export is not ready yet"}
+		}
+		if export.Status.Is(types.FailedDataExport) {
+			return &utils.APIError{Code: http.StatusGone, Message: "Export failed, request a new one"}
+		}
+		file, err := dataExportService.OpenExport(export)
+		if errors.Is(err, store.DataExportNotFoundErr) {
+			return utils.NewNotFoundError("Export", "id", exportID)
+		}
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		name := fmt.Sprintf("discord-go-export-%d.zip", export.ID)
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		http.ServeContent(w, r, name, export.CompletedAt.Time, file)
+		return nil
+	}
+}
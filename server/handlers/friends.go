@@ -3,6 +3,7 @@ package handlers
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/kacperhemperek/discord-go/models"
 	"github.com/kacperhemperek/discord-go/store"
@@ -10,29 +11,62 @@ import (
 	"github.com/kacperhemperek/discord-go/ws"
 	"log/slog"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 )
 
+const (
+	defaultFriendSuggestionsLimit = 20
+	maxFriendSuggestionsLimit     = 50
+)
+
+var friendSuggestionsLimitTooLargeErr = fmt.Errorf("limit can't be larger than %d", maxFriendSuggestionsLimit)
+
 func HandleSendFriendRequest(
 	userService store.UserServiceInterface,
 	notificationStore store.NotificationServiceInterface,
 	notificationWsService ws.NotificationServiceInterface,
 	friendshipService store.FriendshipServiceInterface,
+	friendRequestPolicy store.FriendRequestPolicyInterface,
+	lookupLimiter *utils.RateLimiter,
 	validate *validator.Validate,
 ) utils.APIHandler {
 
 	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
 		body := &SendFriendRequestBody{}
 
-		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+		if err := utils.ReadBody(r, body); err != nil {
 			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
 		}
 
-		userToSendRequest, err := userService.FindUserByEmail(body.Email)
+		body.Handle = utils.NormalizeHandle(body.Handle)
+
+		if err := validate.Struct(body); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+
+		// responses can tell whether the user exists, so requests share the lookup limit to keep enumeration slow
+		allowed, retryAfter := lookupLimiter.Allow(strconv.Itoa(c.User.ID), userLookupInterval)
+		if !allowed {
+			return utils.WriteTooManyRequests(w, "Too many user lookups", retryAfter)
+		}
+
+		var userToSendRequest *models.User
+		var err error
+
+		if body.Handle != "" {
+			userToSendRequest, err = userService.FindUserByHandle(body.Handle)
+		} else {
+			userToSendRequest, err = userService.FindUserByEmail(body.Email)
+		}
 
 		if err != nil {
 			if errors.Is(err, store.UserNotFoundError) {
-				return &utils.APIError{Code: http.StatusNotFound, Message: "User with that email not found", Cause: err}
+				// an unknown email gets the same response as a sent request so it can't be used to check who is registered
+				if body.Handle == "" {
+					return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request sent"})
+				}
+				return utils.NewNotFoundError("user", "handle", body.Handle)
 			}
 			return err
 		}
@@ -41,6 +75,13 @@ func HandleSendFriendRequest(
 			return &utils.APIError{Code: http.StatusBadRequest, Message: "Cannot send friend request to yourself", Cause: nil}
 		}
 
+		// the jwt can hold an outdated username, the notification shows the current one
+		inviter, err := userService.GetUserByID(c.User.ID)
+
+		if err != nil {
+			return err
+		}
+
 		existingFriendship, err := friendshipService.GetFriendshipByUsers(c.User.ID, userToSendRequest.ID)
 
 		if err != nil && !errors.Is(err, sql.ErrNoRows) {
@@ -55,9 +96,28 @@ func HandleSendFriendRequest(
 			return &utils.APIError{Code: http.StatusBadRequest, Message: "Friend request already sent", Cause: nil}
 		}
 
+		if err := friendRequestPolicy.CheckFriendRequest(c.User.ID, userToSendRequest.ID, existingFriendship); err != nil {
+			var cooldownErr *store.FriendRequestCooldownError
+			switch {
+			case errors.As(err, &cooldownErr):
+				return utils.WriteTooManyRequests(w, "Friend request was rejected recently, try again later", cooldownErr.RetryAfter)
+			// respond the same way as for a sent request so the sender can't tell they are blocked
+			case errors.Is(err, store.FriendRequestBlockedErr):
+				return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request sent"})
+			// privacy settings would reveal that the email is registered, so they look like unknown emails
+			case body.Handle == "" && (errors.Is(err, store.FriendRequestsDisabledErr) || errors.Is(err, store.FriendRequestsFriendsOfFriendsErr)):
+				return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request sent"})
+			case errors.Is(err, store.FriendRequestsDisabledErr):
+				return &utils.APIError{Code: http.StatusForbidden, Message: "This user is not accepting friend requests", Cause: err}
+			case errors.Is(err, store.FriendRequestsFriendsOfFriendsErr):
+				return &utils.APIError{Code: http.StatusForbidden, Message: "This user only accepts friend requests from friends of their friends", Cause: err}
+			}
+			return err
+		}
+
 		if existingFriendship != nil && existingFriendship.Status == "rejected" {
 			if existingFriendship.FriendID == c.User.ID {
-				err := friendshipService.DeleteRequestAndSendNew(
+				requestID, err := friendshipService.DeleteRequestAndSendNew(
 					existingFriendship.ID,
 					c.User.ID,
 					userToSendRequest.ID,
@@ -65,21 +125,10 @@ func HandleSendFriendRequest(
 				if err != nil {
 					return err
 				}
-				return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request sent"})
-			}
-
-			if !existingFriendship.StatusChangedAt.Valid {
-				return &utils.APIError{
-					Code:    http.StatusInternalServerError,
-					Message: "Unknown error when sending request",
-					Cause:   errors.New("status changed at is null from database this should not happen if status is changed properly"),
+				if err := notifyAboutFriendRequest(notificationStore, notificationWsService, inviter, userToSendRequest.ID, requestID); err != nil {
+					return err
 				}
-			}
-			now := time.Now()
-			changedAt := existingFriendship.StatusChangedAt.Time
-			timeDiff := now.Sub(changedAt)
-			if timeDiff < time.Hour*24*7 {
-				return &utils.APIError{Code: http.StatusBadRequest, Message: "Friend request already rejected, a week needs to pass to send another request", Cause: nil}
+				return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request sent"})
 			}
 
 			updateFriendshipError := friendshipService.MakeFriendshipPending(existingFriendship.ID)
@@ -87,43 +136,50 @@ func HandleSendFriendRequest(
 			if updateFriendshipError != nil {
 				return updateFriendshipError
 			}
-			n, err := notificationStore.CreateFriendRequestNotification(userToSendRequest.ID, models.FriendRequestNotificationData{
-				TestValue: "this is a test value",
-			})
 
-			if err != nil {
+			if err := notifyAboutFriendRequest(notificationStore, notificationWsService, inviter, userToSendRequest.ID, existingFriendship.ID); err != nil {
 				return err
 			}
 
-			sendNotificationError := notificationWsService.SendNotification(userToSendRequest.ID, n)
-			if sendNotificationError != nil {
-				slog.Info("could not send notification", "error", sendNotificationError)
-			}
-
 			return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request sent"})
 		}
 
-		if err := friendshipService.SendFriendRequest(c.User.ID, userToSendRequest.ID); err != nil {
+		requestID, err := friendshipService.SendFriendRequest(c.User.ID, userToSendRequest.ID)
+		if err != nil {
 			return &utils.APIError{Code: http.StatusInternalServerError, Message: "Unknown error when sending friend request", Cause: err}
 		}
 
-		n, err := notificationStore.CreateFriendRequestNotification(userToSendRequest.ID, models.FriendRequestNotificationData{
-			TestValue: "this is a test value",
-		})
-
-		if err != nil {
+		if err := notifyAboutFriendRequest(notificationStore, notificationWsService, inviter, userToSendRequest.ID, requestID); err != nil {
 			return err
 		}
 
-		sendNotificationError := notificationWsService.SendNotification(userToSendRequest.ID, n)
-		if sendNotificationError != nil {
-			slog.Info("could not send notification", "error", sendNotificationError)
-		}
-
 		return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request sent"})
 	}
 }
 
+func notifyAboutFriendRequest(
+	notificationStore store.NotificationServiceInterface,
+	notificationWsService ws.NotificationServiceInterface,
+	inviter *models.User,
+	recipientID, friendshipID int,
+) error {
+	n, err := notificationStore.CreateFriendRequestNotification(recipientID, models.FriendRequestNotificationData{
+		FriendshipID: friendshipID,
+		Inviter:      inviter.Public(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	sendNotificationError := notificationWsService.SendNotification(recipientID, n)
+	if sendNotificationError != nil {
+		slog.Info("could not send notification", "error", sendNotificationError)
+	}
+
+	return nil
+}
+
 func HandleGetFriendRequests(friendshipService store.FriendshipServiceInterface) utils.APIHandler {
 	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
 		friendRequests, err := friendshipService.GetUsersFriendRequests(c.User.ID)
@@ -136,7 +192,69 @@ func HandleGetFriendRequests(friendshipService store.FriendshipServiceInterface)
 	}
 }
 
-func HandleGetFriends(friendshipService store.FriendshipServiceInterface) utils.APIHandler {
+func HandleGetOutgoingFriendRequests(friendshipService store.FriendshipServiceInterface) utils.APIHandler {
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		friendRequests, err := friendshipService.GetUsersOutgoingFriendRequests(c.User.ID)
+
+		if err != nil {
+			return err
+		}
+
+		return utils.WriteJson(w, http.StatusOK, &utils.JSON{"requests": friendRequests})
+	}
+}
+
+func HandleCancelFriendRequest(
+	friendshipService store.FriendshipServiceInterface,
+	notificationStore store.NotificationServiceInterface,
+	notificationWsService ws.NotificationServiceInterface,
+) utils.APIHandler {
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		requestId, err := utils.GetIntParam(r, "requestId")
+		if err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request", Cause: err}
+		}
+
+		friendship, err := friendshipService.GetFriendshipByID(requestId)
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return utils.NewNotFoundError("friend request", "id", requestId)
+			}
+			return err
+		}
+
+		if friendship.InviterID != c.User.ID {
+			return &utils.APIError{Code: http.StatusForbidden, Message: "You cannot cancel this friend request", Cause: nil}
+		}
+
+		if friendship.Status != "pending" {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Friend request already accepted or rejected", Cause: nil}
+		}
+
+		if err := friendshipService.DeleteFriendship(friendship.ID); err != nil {
+			return err
+		}
+
+		notificationIDs, err := notificationStore.DeleteFriendRequestNotifications(friendship.FriendID, friendship.ID)
+
+		if err != nil {
+			return err
+		}
+
+		err = notificationWsService.SendFriendRequestCancelled(friendship.FriendID, friendship.ID, notificationIDs)
+		if err != nil && !errors.Is(err, ws.NoUserConns) {
+			slog.Info("could not send friend request cancelled event", "error", err)
+		}
+
+		return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request cancelled"})
+	}
+}
+
+func HandleGetFriends(
+	friendshipService store.FriendshipServiceInterface,
+	presenceService ws.PresenceServiceInterface,
+) utils.APIHandler {
 	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
 		users, err := friendshipService.GetFriendsByUserID(c.User.ID)
 
@@ -144,11 +262,21 @@ func HandleGetFriends(friendshipService store.FriendshipServiceInterface) utils.
 			return err
 		}
 
+		for _, user := range users {
+			status := presenceService.GetStatus(user.ID)
+			user.Status = &status
+		}
+
 		return utils.WriteJson(w, http.StatusOK, &utils.JSON{"friends": users})
 	}
 }
 
-func HandleAcceptFriendRequest(friendshipService store.FriendshipServiceInterface) utils.APIHandler {
+func HandleAcceptFriendRequest(
+	friendshipService store.FriendshipServiceInterface,
+	userService store.UserServiceInterface,
+	notificationStore store.NotificationServiceInterface,
+	notificationWsService ws.NotificationServiceInterface,
+) utils.APIHandler {
 	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
 		requestId, err := utils.GetIntParam(r, "requestId")
 		if err != nil {
@@ -198,6 +326,26 @@ func HandleAcceptFriendRequest(friendshipService store.FriendshipServiceInterfac
 			}
 		}
 
+		// the request is already accepted at this point, failing to notify the inviter shouldn't fail it
+		friend, err := userService.GetUserByID(c.User.ID)
+		if err != nil {
+			slog.Error("could not get user for friend request accepted notification", "error", err)
+			return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request accepted"})
+		}
+
+		n, err := notificationStore.CreateFriendRequestAcceptedNotification(friendship.InviterID, &models.FriendRequestAcceptedNotificationData{
+			FriendshipID: friendship.ID,
+			Friend:       friend.Public(),
+		})
+		if err != nil {
+			slog.Error("could not create friend request accepted notification", "error", err)
+			return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request accepted"})
+		}
+
+		if err := notificationWsService.SendNotification(friendship.InviterID, n); err != nil {
+			slog.Info("could not send notification", "error", err)
+		}
+
 		return utils.WriteJson(w, http.StatusOK, utils.JSON{"message": "Friend request accepted"})
 	}
 }
@@ -259,6 +407,153 @@ func HandleRemoveFriend(friendshipService store.FriendshipServiceInterface) util
 	}
 }
 
+// HandleGetFriendProfile returns the user's nickname and note for a friend together with when the friend
+// was last seen, unless they hide it.
+func HandleGetFriendProfile(friendshipService store.FriendshipServiceInterface, userService store.UserServiceInterface) utils.APIHandler {
+	type response struct {
+		Profile    *models.FriendProfile `json:"profile"`
+		LastSeenAt *models.NullTime      `json:"lastSeenAt"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		friendID, err := utils.GetIntParam(r, "friendID")
+		if err != nil {
+			return err
+		}
+		if err := checkIsFriend(friendshipService, c.User.ID, friendID); err != nil {
+			return err
+		}
+		profile, err := friendshipService.GetFriendProfile(c.User.ID, friendID)
+		if err != nil {
+			return err
+		}
+		lastSeenAt, err := userService.GetLastSeenAt(friendID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Profile: profile, LastSeenAt: &lastSeenAt})
+	}
+}
+
+// HandleUpdateFriendProfile replaces the nickname and note the user keeps for a friend, leaving a field out clears it.
+func HandleUpdateFriendProfile(friendshipService store.FriendshipServiceInterface, v *validator.Validate) utils.APIHandler {
+	type request struct {
+		Nickname *string `json:"nickname" validate:"omitempty,max=32"`
+		Note     *string `json:"note" validate:"omitempty,max=1024"`
+	}
+	type response struct {
+		Profile *models.FriendProfile `json:"profile"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		friendID, err := utils.GetIntParam(r, "friendID")
+		if err != nil {
+			return err
+		}
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		if err := checkIsFriend(friendshipService, c.User.ID, friendID); err != nil {
+			return err
+		}
+		profile, err := friendshipService.SaveFriendProfile(c.User.ID, &models.FriendProfile{
+			FriendID: friendID,
+			Nickname: nilIfBlank(body.Nickname),
+			Note:     nilIfBlank(body.Note),
+		})
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Profile: profile})
+	}
+}
+
+func checkIsFriend(friendshipService store.FriendshipServiceInterface, userID, friendID int) error {
+	friendship, err := friendshipService.GetFriendshipByUsers(userID, friendID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return utils.NewNotFoundError("friend", "id", friendID)
+	}
+	if err != nil {
+		return err
+	}
+	if friendship.Status != "accepted" {
+		return utils.NewNotFoundError("friend", "id", friendID)
+	}
+	return nil
+}
+
+func nilIfBlank(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func HandleGetMutualFriends(
+	friendshipService store.FriendshipServiceInterface,
+	blockService store.BlockServiceInterface,
+) utils.APIHandler {
+	type response struct {
+		Friends []*models.PublicUser `json:"friends"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		userID, err := utils.GetIntParam(r, "userID")
+		if err != nil {
+			return err
+		}
+		if userID == c.User.ID {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Cannot get mutual friends with yourself"}
+		}
+		blocked, err := blockService.IsBlockedEitherWay(c.User.ID, userID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return utils.NewNotFoundError("user", "id", userID)
+		}
+		friends, err := friendshipService.GetMutualFriends(c.User.ID, userID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Friends: friends})
+	}
+}
+
+func HandleGetFriendSuggestions(friendshipService store.FriendshipServiceInterface) utils.APIHandler {
+	type response struct {
+		Suggestions []*models.FriendSuggestion `json:"suggestions"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		limitParam := r.URL.Query().Get("limit")
+		limit, err := store.NewLimitFilter(limitParam)
+		if err != nil {
+			return utils.NewInvalidQueryParamError("limit", limitParam, err)
+		}
+		if limit != nil && *limit < 1 {
+			return utils.NewInvalidQueryParamError("limit", limitParam, store.LimitNumberTooSmallErr)
+		}
+		if limit != nil && *limit > maxFriendSuggestionsLimit {
+			return utils.NewInvalidQueryParamError("limit", limitParam, friendSuggestionsLimitTooLargeErr)
+		}
+		pageSize := defaultFriendSuggestionsLimit
+		if limit != nil {
+			pageSize = *limit
+		}
+		suggestions, err := friendshipService.GetFriendSuggestions(c.User.ID, pageSize)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Suggestions: suggestions})
+	}
+}
+
 func HandleGetFriendRequestNotifications(notificationStore store.NotificationServiceInterface) utils.APIHandler {
 	type response struct {
 		Notifications []*models.FriendRequestNotification `json:"notifications"`
@@ -293,8 +588,10 @@ func HandleGetFriendRequestNotifications(notificationStore store.NotificationSer
 	}
 }
 
+// SendFriendRequestBody identifies the user by handle or, for users who know it, by email.
 type SendFriendRequestBody struct {
-	Email string `json:"email" validate:"required,email"`
+	Handle string `json:"handle" validate:"required_without=Email,omitempty,handle"`
+	Email  string `json:"email" validate:"required_without=Handle,omitempty,email"`
 }
 
 type AcceptFriendRequestBody struct {
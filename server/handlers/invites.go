@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/types"
+	"github.com/kacperhemperek/discord-go/utils"
+	"net/http"
+	"time"
+)
+
+func HandleCreateChatInvite(
+	chatService store.ChatServiceInterface,
+	inviteService store.InviteServiceInterface,
+	validate *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		MaxUses          *int `json:"maxUses" validate:"omitempty,min=1,max=1000"`
+		ExpiresInSeconds *int `json:"expiresInSeconds" validate:"omitempty,min=60,max=2592000"`
+	}
+
+	type response struct {
+		Invite *models.ChatInvite `json:"invite"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
+			}
+		}
+		chat, err := chatService.GetChatByID(chatID)
+		if err != nil {
+			return err
+		}
+		if !chat.Type.Is(types.GroupChat) {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Invites can only be created for group chats",
+			}
+		}
+		var expiresAt *time.Time
+		if body.ExpiresInSeconds != nil {
+			t := time.Now().UTC().Add(time.Duration(*body.ExpiresInSeconds) * time.Second)
+			expiresAt = &t
+		}
+		invite, err := inviteService.CreateInvite(chat.ID, c.User.ID, body.MaxUses, expiresAt)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusCreated, &response{Invite: invite})
+	}
+}
+
+func HandleGetChatInvites(inviteService store.InviteServiceInterface) utils.APIHandler {
+	type response struct {
+		Invites []*models.ChatInvite `json:"invites"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		invites, err := inviteService.GetChatInvites(chatID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Invites: invites})
+	}
+}
+
+func HandleGetChatInviteUses(inviteService store.InviteServiceInterface) utils.APIHandler {
+	type response struct {
+		Uses []*models.ChatInviteUse `json:"uses"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		invite, err := getChatInviteFromParams(r, inviteService)
+		if err != nil {
+			return err
+		}
+		uses, err := inviteService.GetInviteUses(invite.ID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Uses: uses})
+	}
+}
+
+func HandleRevokeChatInvite(inviteService store.InviteServiceInterface) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		invite, err := getChatInviteFromParams(r, inviteService)
+		if err != nil {
+			return err
+		}
+		err = inviteService.RevokeInvite(invite.ID, &store.ChatAuditLogInput{
+			ChatID:  invite.ChatID,
+			ActorID: c.User.ID,
+			Action:  types.InviteRevokedAuditAction,
+			Before:  map[string]string{"code": invite.Code},
+		})
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Invite revoked successfully"})
+	}
+}
+
+func HandleGetInvitePreview(inviteService store.InviteServiceInterface) utils.APIHandler {
+	type response struct {
+		Invite *models.ChatInvitePreview `json:"invite"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		code, err := utils.GetStringParam(r, "code")
+		if err != nil {
+			return err
+		}
+		preview, err := inviteService.GetInvitePreview(code)
+		if err != nil {
+			return inviteError(code, err)
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Invite: preview})
+	}
+}
+
+func HandleAcceptInvite(inviteService store.InviteServiceInterface) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+		ChatID  int    `json:"chatId"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		code, err := utils.GetStringParam(r, "code")
+		if err != nil {
+			return err
+		}
+		invite, err := inviteService.AcceptInvite(code, c.User.ID)
+		if err != nil {
+			return inviteError(code, err)
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{
+			Message: "Joined chat successfully",
+			ChatID:  invite.ChatID,
+		})
+	}
+}
+
+// getChatInviteFromParams finds the invite from the code param and makes sure it belongs to the chatID param,
+// so admins of one chat cannot manage invites of another.
+func getChatInviteFromParams(r *http.Request, inviteService store.InviteServiceInterface) (*models.ChatInvite, error) {
+	chatID, err := utils.GetIntParam(r, "chatID")
+	if err != nil {
+		return nil, err
+	}
+	code, err := utils.GetStringParam(r, "code")
+	if err != nil {
+		return nil, err
+	}
+	invite, err := inviteService.GetInviteByCode(code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewNotFoundError("invite", "code", code)
+		}
+		return nil, err
+	}
+	if invite.ChatID != chatID {
+		return nil, utils.NewNotFoundError("invite", "code", code)
+	}
+	return invite, nil
+}
+
+func inviteError(code string, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return utils.NewNotFoundError("invite", "code", code)
+	case errors.Is(err, store.InviteExpiredErr),
+		errors.Is(err, store.InviteRevokedErr),
+		errors.Is(err, store.InviteUsedUpErr):
+		return &utils.APIError{
+			Code:    http.StatusGone,
+			Message: err.Error(),
+			Cause:   err,
+		}
+	case errors.Is(err, store.AlreadyChatMemberErr):
+		return &utils.APIError{
+			Code:    http.StatusConflict,
+			Message: "You are already a member of this chat",
+			Cause:   err,
+		}
+	default:
+		return err
+	}
+}
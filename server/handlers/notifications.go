@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"github.com/go-playground/validator/v10"
 	"github.com/kacperhemperek/discord-go/models"
@@ -13,17 +14,33 @@ import (
 	"net/http"
 )
 
-func HandleSubscribeNotifications(notificationWsService ws.NotificationServiceInterface) utils.APIHandler {
+// HandleSubscribeNotifications keeps the user's notification socket open, the socket also drives the user's presence
+// and clients report going idle or active on it with SET_PRESENCE messages.
+func HandleSubscribeNotifications(
+	notificationWsService ws.NotificationServiceInterface,
+	presenceService ws.PresenceServiceInterface,
+) utils.APIHandler {
+	type presenceMessage struct {
+		Type string `json:"type"`
+		Idle bool   `json:"idle"`
+	}
 
 	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
 		connID := notificationWsService.AddConn(c.User.ID, c.Conn)
+		presenceService.Connect(c.User.ID, connID)
 		for {
-			_, _, err := c.Conn.ReadMessage()
+			_, data, err := c.Conn.ReadMessage()
 			if err != nil {
 				break
 			}
+			msg := &presenceMessage{}
+			if err := json.Unmarshal(data, msg); err != nil || msg.Type != ws.SetPresence {
+				continue
+			}
+			presenceService.SetIdle(c.User.ID, connID, msg.Idle)
 		}
 		slog.Info("closing user notification connection", "userID", c.User.ID)
+		presenceService.Disconnect(c.User.ID, connID)
 		return notificationWsService.RemoveConn(c.User.ID, connID)
 	}
 }
@@ -45,6 +62,48 @@ func HandleMarkFriendRequestNotificationsAsSeen(notificationsStore store.Notific
 	}
 }
 
+func HandleMarkFriendRequestAcceptedNotificationsAsSeen(notificationsStore store.NotificationServiceInterface) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		fran := types.FriendRequestAcceptedNotification
+		if err := notificationsStore.MarkUsersNotificationsAsSeen(c.User.ID, fran.String()); err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{
+			Message: "notifications marked as seen",
+		})
+	}
+}
+
+func HandleGetFriendRequestAcceptedNotifications(notificationsStore store.NotificationServiceInterface) utils.APIHandler {
+	type response struct {
+		Notifications []*models.FriendRequestAcceptedNotification `json:"notifications"`
+	}
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		var seenFilter *store.BoolFilter
+		seen := r.URL.Query().Get("seen")
+		if seen != "" {
+			filter, err := store.NewBoolFilter(seen)
+			if err != nil {
+				return utils.NewInvalidQueryParamError("seen", seen, err)
+			}
+			seenFilter = filter
+		}
+		limit := r.URL.Query().Get("limit")
+		limitFilter, err := store.NewLimitFilter(limit)
+		if err != nil {
+			return utils.NewInvalidQueryParamError("limit", limit, err)
+		}
+		notifications, err := notificationsStore.GetUserFriendRequestAcceptedNotifications(c.User.ID, seenFilter, limitFilter)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Notifications: notifications})
+	}
+}
+
 func HandleMarkNewMessageNotificationsAsSeen(notificationsStore store.NotificationServiceInterface, chatsStore store.ChatServiceInterface, validate *validator.Validate) utils.APIHandler {
 	type request struct {
 		ChatID int `json:"chatId" validate:"number,min=1"`
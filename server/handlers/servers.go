@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/utils"
+	"github.com/kacperhemperek/discord-go/ws"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+type ServerRequestBody struct {
+	Name    string  `json:"name" validate:"required,min=2,max=100"`
+	IconURL *string `json:"iconUrl" validate:"omitempty,url"`
+}
+
+type ChannelRequestBody struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+func HandleCreateServer(serverService store.ServerServiceInterface, validate *validator.Validate) utils.APIHandler {
+	type response struct {
+		Server *models.ServerWithChannels `json:"server"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		body := &ServerRequestBody{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
+			}
+		}
+		server, err := serverService.CreateServer(c.User.ID, body.Name, body.IconURL)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusCreated, &response{Server: server})
+	}
+}
+
+func HandleGetUsersServers(serverService store.ServerServiceInterface) utils.APIHandler {
+	type response struct {
+		Servers []*models.Server `json:"servers"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		servers, err := serverService.GetUsersServers(c.User.ID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Servers: servers})
+	}
+}
+
+func HandleGetServer(serverService store.ServerServiceInterface) utils.APIHandler {
+	type response struct {
+		Server *models.ServerWithChannels `json:"server"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		serverID, err := utils.GetIntParam(r, "serverID")
+		if err != nil {
+			return err
+		}
+		server, err := serverService.GetServerByID(serverID)
+		if err != nil {
+			return err
+		}
+		channels, err := serverService.GetServerChannels(serverID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{
+			Server: &models.ServerWithChannels{
+				Channels: channels,
+				Server:   *server,
+			},
+		})
+	}
+}
+
+func HandleUpdateServer(serverService store.ServerServiceInterface, validate *validator.Validate) utils.APIHandler {
+	type response struct {
+		Server *models.Server `json:"server"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		serverID, err := utils.GetIntParam(r, "serverID")
+		if err != nil {
+			return err
+		}
+		body := &ServerRequestBody{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
+			}
+		}
+		server, err := serverService.UpdateServer(serverID, body.Name, body.IconURL)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Server: server})
+	}
+}
+
+func HandleDeleteServer(
+	serverService store.ServerServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	voiceWsService ws.VoiceServiceInterface,
+) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		serverID, err := utils.GetIntParam(r, "serverID")
+		if err != nil {
+			return err
+		}
+		server, err := serverService.GetServerByID(serverID)
+		if err != nil {
+			return err
+		}
+		if server.OwnerID != c.User.ID {
+			return &utils.APIError{
+				Code:    http.StatusForbidden,
+				Message: "Only the server owner can delete the server",
+			}
+		}
+		channelIDs, err := serverService.DeleteServer(serverID)
+		if err != nil {
+			return err
+		}
+		for _, channelID := range channelIDs {
+			closeDeletedChat(chatWsService, voiceWsService, channelID)
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Server deleted successfully"})
+	}
+}
+
+func HandleGetServerMembers(serverService store.ServerServiceInterface) utils.APIHandler {
+	type response struct {
+		Members []*models.ServerMember `json:"members"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		serverID, err := utils.GetIntParam(r, "serverID")
+		if err != nil {
+			return err
+		}
+		members, err := serverService.GetServerMembers(serverID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Members: members})
+	}
+}
+
+func HandleAddServerMembers(
+	serverService store.ServerServiceInterface,
+	userService store.UserServiceInterface,
+	validate *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		UserIDs []int `json:"userIds" validate:"min=1,unique,dive,min=1"`
+	}
+
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		serverID, err := utils.GetIntParam(r, "serverID")
+		if err != nil {
+			return err
+		}
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
+			}
+		}
+		users, err := userService.GetUsersByIDs(body.UserIDs)
+		if err != nil {
+			return err
+		}
+		if len(users) != len(body.UserIDs) {
+			return &utils.APIError{
+				Message: "Not every user exists from provided list",
+				Code:    http.StatusNotFound,
+			}
+		}
+		if err := serverService.AddServerMembers(serverID, body.UserIDs); err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Members added successfully"})
+	}
+}
+
+func HandleLeaveServer(
+	serverService store.ServerServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	voiceWsService ws.VoiceServiceInterface,
+) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		serverID, err := utils.GetIntParam(r, "serverID")
+		if err != nil {
+			return err
+		}
+		server, err := serverService.GetServerByID(serverID)
+		if err != nil {
+			return err
+		}
+		if server.OwnerID == c.User.ID {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Server owner cannot leave the server",
+			}
+		}
+		if err := serverService.RemoveServerMember(serverID, c.User.ID); err != nil {
+			return err
+		}
+		channels, err := serverService.GetServerChannels(serverID)
+		if err != nil {
+			return err
+		}
+		for _, channel := range channels {
+			if err := chatWsService.CloseUserConns(channel.ID, c.User.ID); err != nil {
+				slog.Error("could not close connections of user leaving server", "chatID", channel.ID, "userID", c.User.ID, "error", err)
+			}
+			voiceWsService.RemoveUser(channel.ID, c.User.ID)
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Left server successfully"})
+	}
+}
+
+func HandleCreateChannel(serverService store.ServerServiceInterface, validate *validator.Validate) utils.APIHandler {
+	type response struct {
+		Channel *models.Chat `json:"channel"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		serverID, err := utils.GetIntParam(r, "serverID")
+		if err != nil {
+			return err
+		}
+		body := &ChannelRequestBody{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
+			}
+		}
+		channel, err := serverService.CreateChannel(serverID, body.Name)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusCreated, &response{Channel: channel})
+	}
+}
+
+func HandleUpdateChannel(
+	serverService store.ServerServiceInterface,
+	chatService store.ChatServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	validate *validator.Validate,
+) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := getServerChannelIDFromParams(r, serverService)
+		if err != nil {
+			return err
+		}
+		body := &ChannelRequestBody{}
+		if err := utils.ReadAndValidateBody(r, body, validate); err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body is not valid",
+				Cause:   err,
+			}
+		}
+		oldChannel, err := chatService.GetChatByID(chatID)
+		if err != nil {
+			return err
+		}
+		changes := &models.ChatUpdate{Name: &body.Name}
+		if _, err := chatService.UpdateChat(chatID, c.User.ID, changes, chatUpdatedAuditEntry(c.User.ID, oldChannel, changes)); err != nil {
+			return err
+		}
+		err = chatWsService.BroadcastChatUpdated(chatID, changes)
+		if err != nil && !errors.Is(err, ws.ChatNotFoundErr) {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Channel updated successfully"})
+	}
+}
+
+func HandleDeleteChannel(
+	serverService store.ServerServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	voiceWsService ws.VoiceServiceInterface,
+) utils.APIHandler {
+	type response struct {
+		Message string `json:"message"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		serverID, err := utils.GetIntParam(r, "serverID")
+		if err != nil {
+			return err
+		}
+		chatID, err := getServerChannelIDFromParams(r, serverService)
+		if err != nil {
+			return err
+		}
+		if err := serverService.DeleteChannel(serverID, chatID); err != nil {
+			return err
+		}
+		closeDeletedChat(chatWsService, voiceWsService, chatID)
+		return utils.WriteJson(w, http.StatusOK, &response{Message: "Channel deleted successfully"})
+	}
+}
+
+// getServerChannelIDFromParams returns the chatID param after making sure it is a channel of the serverID param.
+func getServerChannelIDFromParams(r *http.Request, serverService store.ServerServiceInterface) (int, error) {
+	serverID, err := utils.GetIntParam(r, "serverID")
+	if err != nil {
+		return 0, err
+	}
+	chatID, err := utils.GetIntParam(r, "chatID")
+	if err != nil {
+		return 0, err
+	}
+	channels, err := serverService.GetServerChannels(serverID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	isServerChannel := slices.ContainsFunc(channels, func(channel *models.Chat) bool {
+		return channel.ID == chatID
+	})
+	if !isServerChannel {
+		return 0, utils.NewNotFoundError("channel", "id", chatID)
+	}
+	return chatID, nil
+}
@@ -0,0 +1,597 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/types"
+	"github.com/kacperhemperek/discord-go/utils"
+	"github.com/kacperhemperek/discord-go/ws"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxAvatarSize = 4 << 20
+
+// maxAvatarDimension limits the width and height of uploaded avatars so decoding them stays cheap.
+const maxAvatarDimension = 4096
+
+// Avatars are stored in these sizes, in pixels.
+const (
+	smallAvatarSize  = 64
+	mediumAvatarSize = 128
+	largeAvatarSize  = 256
+)
+
+// userLookupInterval is how often a single user can look up handles, slow enough to make enumerating users impractical.
+const userLookupInterval = 2 * time.Second
+
+func HandleLookupUser(
+	userService store.UserServiceInterface,
+	blockService store.BlockServiceInterface,
+	lookupLimiter *utils.RateLimiter,
+) utils.APIHandler {
+	type response struct {
+		User *models.PublicUser `json:"user"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		handle := utils.NormalizeHandle(r.URL.Query().Get("handle"))
+		if !utils.IsValidHandle(handle) {
+			return utils.NewInvalidQueryParamError("handle", handle, nil)
+		}
+		allowed, retryAfter := lookupLimiter.Allow(strconv.Itoa(c.User.ID), userLookupInterval)
+		if !allowed {
+			return utils.WriteTooManyRequests(w, "Too many user lookups", retryAfter)
+		}
+		user, err := userService.FindUserByHandle(handle)
+		if errors.Is(err, store.UserNotFoundError) {
+			return utils.NewNotFoundError("user", "handle", handle)
+		}
+		if err != nil {
+			return err
+		}
+		// users who blocked the caller look the same as users who don't exist
+		blocked, err := blockService.IsBlockedEitherWay(c.User.ID, user.ID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return utils.NewNotFoundError("user", "handle", handle)
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{User: user.Public()})
+	}
+}
+
+func HandleGetPrivacySettings(userService store.UserServiceInterface) utils.APIHandler {
+	type response struct {
+		Settings *models.PrivacySettings `json:"settings"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		settings, err := userService.GetPrivacySettings(c.User.ID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Settings: settings})
+	}
+}
+
+func HandleUpdatePrivacySettings(userService store.UserServiceInterface, v *validator.Validate) utils.APIHandler {
+	type request struct {
+		FriendRequests *types.FriendRequestPrivacy `json:"friendRequests"`
+		ShowLastSeen   *bool                       `json:"showLastSeen"`
+	}
+	type response struct {
+		Settings *models.PrivacySettings `json:"settings"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		settings, err := userService.GetPrivacySettings(c.User.ID)
+		if err != nil {
+			return err
+		}
+		if body.FriendRequests != nil {
+			settings.FriendRequests = *body.FriendRequests
+		}
+		if body.ShowLastSeen != nil {
+			settings.ShowLastSeen = *body.ShowLastSeen
+		}
+		if err := userService.UpdatePrivacySettings(c.User.ID, settings); err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{Settings: settings})
+	}
+}
+
+func HandleGetCustomStatus(userService store.UserServiceInterface) utils.APIHandler {
+	type response struct {
+		CustomStatus *models.CustomStatus `json:"customStatus"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		status, err := userService.GetCustomStatus(c.User.ID)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{CustomStatus: status})
+	}
+}
+
+// HandleUpdateCustomStatus replaces the user's custom status, sending it without text, emoji and
+// do not disturb clears it.
+func HandleUpdateCustomStatus(
+	userService store.UserServiceInterface,
+	presenceService ws.PresenceServiceInterface,
+	v *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		Text         *string    `json:"text" validate:"omitempty,max=128"`
+		Emoji        *string    `json:"emoji" validate:"omitempty,max=64"`
+		ExpiresAt    *time.Time `json:"expiresAt"`
+		DoNotDisturb bool       `json:"doNotDisturb"`
+	}
+	type response struct {
+		CustomStatus *models.CustomStatus `json:"customStatus"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Custom status has to expire in the future"}
+		}
+		var status *models.CustomStatus
+		text, emoji := nilIfBlank(body.Text), nilIfBlank(body.Emoji)
+		if text != nil || emoji != nil || body.DoNotDisturb {
+			status = &models.CustomStatus{
+				Text:         text,
+				Emoji:        emoji,
+				DoNotDisturb: body.DoNotDisturb,
+			}
+			if body.ExpiresAt != nil {
+				status.ExpiresAt = models.NullTime{Time: body.ExpiresAt.UTC(), Valid: true}
+			}
+		}
+		saved, err := userService.SetCustomStatus(c.User.ID, status)
+		if err != nil {
+			return err
+		}
+		presenceService.BroadcastCustomStatus(c.User.ID, saved)
+		return utils.WriteJson(w, http.StatusOK, &response{CustomStatus: saved})
+	}
+}
+
+// HandleGetUser returns the public profile of the user, users blocked either way are reported as not found.
+func HandleGetUser(userService store.UserServiceInterface, blockService store.BlockServiceInterface) utils.APIHandler {
+	type response struct {
+		User *models.PublicUser `json:"user"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		userID, err := utils.GetIntParam(r, "userID")
+		if err != nil {
+			return err
+		}
+		blocked, err := blockService.IsBlockedEitherWay(c.User.ID, userID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return utils.NewNotFoundError("user", "id", userID)
+		}
+		user, err := userService.GetUserByID(userID)
+		if errors.Is(err, store.UserNotFoundError) {
+			return utils.NewNotFoundError("user", "id", userID)
+		}
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{User: user.Public()})
+	}
+}
+
+// HandleUpdateProfile changes the profile fields sent in the body, an empty string clears the field.
+func HandleUpdateProfile(
+	userService store.UserServiceInterface,
+	presenceService ws.PresenceServiceInterface,
+	v *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		DisplayName *string `json:"displayName" validate:"omitempty,max=32"`
+		Bio         *string `json:"bio" validate:"omitempty,max=190"`
+		BannerColor *string `json:"bannerColor" validate:"omitempty,hexcolor,len=7"`
+	}
+	type response struct {
+		User *models.User `json:"user"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		if body.DisplayName == nil && body.Bio == nil && body.BannerColor == nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Nothing to update"}
+		}
+		update := &models.ProfileUpdate{
+			DisplayName: trimSpace(body.DisplayName),
+			Bio:         trimSpace(body.Bio),
+			BannerColor: trimSpace(body.BannerColor),
+		}
+		if update.BannerColor != nil {
+			color := strings.ToLower(*update.BannerColor)
+			update.BannerColor = &color
+		}
+		user, err := userService.UpdateProfile(c.User.ID, update)
+		if err != nil {
+			return err
+		}
+		presenceService.BroadcastUserUpdated(user.Public())
+		return utils.WriteJson(w, http.StatusOK, &response{User: user})
+	}
+}
+
+// HandleUploadAvatar stores the uploaded image cropped to a square in each of the avatar sizes.
+func HandleUploadAvatar(
+	userService store.UserServiceInterface,
+	presenceService ws.PresenceServiceInterface,
+	fileStorage store.FileStorageInterface,
+) utils.APIHandler {
+	type response struct {
+		User *models.User `json:"user"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		oldUser, err := userService.GetUserByID(c.User.ID)
+		if err != nil {
+			return err
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxAvatarSize)
+		file, _, err := r.FormFile("avatar")
+		if err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Avatar has to be an image sent in the avatar field and can't be larger than 4MB",
+				Cause:   err,
+			}
+		}
+		defer file.Close()
+		img, err := decodeAvatar(file)
+		if err != nil {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("Avatar has to be a png, jpeg or gif image no larger than %dx%d", maxAvatarDimension, maxAvatarDimension),
+				Cause:   err,
+			}
+		}
+		avatar, err := saveAvatar(fileStorage, img)
+		if err != nil {
+			return err
+		}
+		user, err := userService.SetAvatar(c.User.ID, avatar)
+		if err != nil {
+			deleteAvatar(fileStorage, c.User.ID, avatar)
+			return err
+		}
+		if oldUser.Avatar != nil {
+			deleteAvatar(fileStorage, c.User.ID, oldUser.Avatar)
+		}
+		presenceService.BroadcastUserUpdated(user.Public())
+		return utils.WriteJson(w, http.StatusOK, &response{User: user})
+	}
+}
+
+func HandleDeleteAvatar(
+	userService store.UserServiceInterface,
+	presenceService ws.PresenceServiceInterface,
+	fileStorage store.FileStorageInterface,
+) utils.APIHandler {
+	type response struct {
+		User *models.User `json:"user"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		oldUser, err := userService.GetUserByID(c.User.ID)
+		if err != nil {
+			return err
+		}
+		user, err := userService.SetAvatar(c.User.ID, nil)
+		if err != nil {
+			return err
+		}
+		if oldUser.Avatar != nil {
+			deleteAvatar(fileStorage, c.User.ID, oldUser.Avatar)
+		}
+		presenceService.BroadcastUserUpdated(user.Public())
+		return utils.WriteJson(w, http.StatusOK, &response{User: user})
+	}
+}
+
+// decodeAvatar checks the image dimensions before decoding it, so small files that decode
+// into huge images are rejected early.
+func decodeAvatar(file io.ReadSeeker) (image.Image, error) {
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > maxAvatarDimension || config.Height > maxAvatarDimension {
+		return nil, fmt.Errorf("avatar is %dx%d", config.Width, config.Height)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+func saveAvatar(fileStorage store.FileStorageInterface, img image.Image) (*models.Avatar, error) {
+	urls := make([]string, 0, 3)
+	for _, size := range []int{smallAvatarSize, mediumAvatarSize, largeAvatarSize} {
+		url, err := saveAvatarSize(fileStorage, img, size)
+		if err != nil {
+			for _, saved := range urls {
+				_ = fileStorage.Delete(saved)
+			}
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return &models.Avatar{Small: urls[0], Medium: urls[1], Large: urls[2]}, nil
+}
+
+func saveAvatarSize(fileStorage store.FileStorageInterface, img image.Image, size int) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, utils.ResizeSquare(img, size)); err != nil {
+		return "", err
+	}
+	return fileStorage.SaveImage("avatars", buf)
+}
+
+func deleteAvatar(fileStorage store.FileStorageInterface, userID int, avatar *models.Avatar) {
+	for _, url := range avatar.URLs() {
+		if err := fileStorage.Delete(url); err != nil {
+			slog.Error("could not delete avatar", "userID", userID, "error", err)
+		}
+	}
+}
+
+// trimSpace trims the value but keeps it when it ends up empty, unlike nilIfBlank.
+func trimSpace(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	return &trimmed
+}
+
+// HandleChangePassword replaces the password of the logged-in user, every other session is logged out
+// and the current one gets new tokens.
+func HandleChangePassword(userService store.UserServiceInterface, v *validator.Validate) utils.APIHandler {
+	type request struct {
+		CurrentPassword string `json:"currentPassword" validate:"required"`
+		Password        string `json:"password" validate:"required,max=24,min=8"`
+		ConfirmPassword string `json:"confirmPassword" validate:"required,max=24,min=8"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		if body.Password != body.ConfirmPassword {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Passwords do not match"}
+		}
+		user, err := getUserWithPassword(userService, c.User.ID, body.CurrentPassword)
+		if err != nil {
+			return err
+		}
+		hashedPassword, err := utils.EncryptPassword(body.Password)
+		if err != nil {
+			return err
+		}
+		sessionVersion, err := userService.UpdatePassword(user.ID, hashedPassword)
+		if err != nil {
+			return err
+		}
+		if err := setAuthTokens(w, user, sessionVersion); err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &utils.JSON{
+			"message": "password was changed",
+		})
+	}
+}
+
+// HandleChangeEmail sends a confirmation link to the new email, the email is switched only once
+// the link is opened.
+func HandleChangeEmail(userService store.UserServiceInterface, mailer store.Mailer, v *validator.Validate) utils.APIHandler {
+	type request struct {
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		user, err := getUserWithPassword(userService, c.User.ID, body.Password)
+		if err != nil {
+			return err
+		}
+		if user.Email == body.Email {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "This is already your email"}
+		}
+		_, err = userService.FindUserByEmail(body.Email)
+		if err == nil {
+			return &utils.APIError{Code: http.StatusConflict, Message: "User with this email already exists"}
+		}
+		if !errors.Is(err, store.UserNotFoundError) {
+			return err
+		}
+		sessionVersion, err := userService.GetSessionVersion(user.ID)
+		if err != nil {
+			return err
+		}
+		token, err := utils.NewEmailChangeToken(user.ID, body.Email, sessionVersion)
+		if err != nil {
+			return err
+		}
+		link := utils.ClientURL("/confirm-email?token=" + url.QueryEscape(token))
+		err = mailer.Send(&store.Email{
+			To:      body.Email,
+			Subject: "Confirm your new email",
+			Body:    fmt.Sprintf("Open the link below to start using this email, it expires in 24 hours.\n\n%s", link),
+		})
+		if err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusAccepted, &utils.JSON{
+			"message": "confirmation link was sent to the new email",
+		})
+	}
+}
+
+// HandleConfirmEmailChange switches the user to the email from the confirmation link, every other
+// session is logged out and the current one gets tokens with the new email.
+func HandleConfirmEmailChange(userService store.UserServiceInterface, v *validator.Validate) utils.APIHandler {
+	type request struct {
+		Token string `json:"token" validate:"required"`
+	}
+	type response struct {
+		User *models.User `json:"user"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		claims, err := utils.ParseEmailChangeToken(body.Token)
+		if err != nil || claims.UserID != c.User.ID {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Confirmation link is invalid or expired", Cause: err}
+		}
+		sessionVersion, err := userService.ChangeEmail(c.User.ID, claims.Email, claims.SessionVersion)
+		if errors.Is(err, store.SessionVersionChangedError) {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Confirmation link is invalid or expired", Cause: err}
+		}
+		if errors.Is(err, store.EmailTakenError) {
+			return &utils.APIError{Code: http.StatusConflict, Message: "User with this email already exists", Cause: err}
+		}
+		if err != nil {
+			return err
+		}
+		user, err := userService.GetUserByID(c.User.ID)
+		if err != nil {
+			return err
+		}
+		if err := setAuthTokens(w, user, sessionVersion); err != nil {
+			return err
+		}
+		return utils.WriteJson(w, http.StatusOK, &response{User: user})
+	}
+}
+
+const ownsChatsMessage = "Transfer ownership of your group chats and servers before deleting your account"
+
+// HandleDeleteAccount deletes the logged-in user's account, the user's sockets are closed before
+// anything is removed so no new messages can be sent in the meantime.
+func HandleDeleteAccount(
+	userService store.UserServiceInterface,
+	fileStorage store.FileStorageInterface,
+	dataExportService store.DataExportServiceInterface,
+	notificationWsService ws.NotificationServiceInterface,
+	chatWsService ws.ChatServiceInterface,
+	v *validator.Validate,
+) utils.APIHandler {
+	type request struct {
+		Password string `json:"password" validate:"required"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		body := &request{}
+		if err := utils.ReadAndValidateBody(r, body, v); err != nil {
+			return &utils.APIError{Code: http.StatusBadRequest, Message: "Invalid request body", Cause: err}
+		}
+		user, err := getUserWithPassword(userService, c.User.ID, body.Password)
+		if err != nil {
+			return err
+		}
+		ownsChats, err := userService.OwnsChats(user.ID)
+		if err != nil {
+			return err
+		}
+		if ownsChats {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: ownsChatsMessage,
+			}
+		}
+		if err := notificationWsService.CloseUserConns(user.ID); err != nil {
+			slog.Error("could not close notification connections of deleted user", "userID", user.ID, "error", err)
+		}
+		if err := chatWsService.CloseAllUserConns(user.ID); err != nil {
+			slog.Error("could not close chat connections of deleted user", "userID", user.ID, "error", err)
+		}
+		exportFiles, err := dataExportService.GetExportFileNames(user.ID)
+		if err != nil {
+			return err
+		}
+		chatIDs, err := userService.DeleteUser(user.ID)
+		if errors.Is(err, store.UserOwnsChatsError) {
+			return &utils.APIError{
+				Code:    http.StatusBadRequest,
+				Message: ownsChatsMessage,
+				Cause:   err,
+			}
+		}
+		if err != nil {
+			return err
+		}
+		for _, chatID := range chatIDs {
+			err := chatWsService.CloseChat(chatID)
+			if err != nil && !errors.Is(err, ws.ChatNotFoundErr) {
+				slog.Error("could not close deleted chat connections", "chatID", chatID, "error", err)
+			}
+		}
+		if user.Avatar != nil {
+			deleteAvatar(fileStorage, user.ID, user.Avatar)
+		}
+		dataExportService.RemoveExportFiles(exportFiles)
+		utils.RemoveAuthTokensCookies(w)
+		return utils.WriteJson(w, http.StatusOK, &utils.JSON{
+			"message": "account was deleted",
+		})
+	}
+}
+
+// getUserWithPassword returns the user after checking the password they confirmed the action with.
+func getUserWithPassword(userService store.UserServiceInterface, userID int, password string) (*models.User, error) {
+	user, err := userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.CheckPassword(user.Password, password); err != nil {
+		return nil, &utils.APIError{Code: http.StatusForbidden, Message: "Password is incorrect", Cause: err}
+	}
+	return user, nil
+}
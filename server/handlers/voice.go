@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/kacperhemperek/discord-go/utils"
+	"github.com/kacperhemperek/discord-go/ws"
+	"log/slog"
+	"net/http"
+)
+
+func HandleConnectToVoice(voiceService ws.VoiceServiceInterface) utils.APIHandler {
+	return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+		chatID, err := utils.GetIntParam(r, "chatID")
+		if err != nil {
+			return err
+		}
+		peerID, err := voiceService.Join(chatID, c.User.ID, c.Conn)
+		if err != nil {
+			return err
+		}
+		for {
+			mType, msg, err := c.Conn.ReadMessage()
+			if err != nil {
+				break
+			}
+			if mType != websocket.TextMessage {
+				continue
+			}
+			if err := voiceService.HandleMessage(chatID, peerID, msg); err != nil {
+				slog.Info("could not handle voice message", "chatID", chatID, "peerID", peerID, "error", err)
+			}
+		}
+		return voiceService.Leave(chatID, peerID)
+	}
+}
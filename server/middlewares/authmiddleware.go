@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/kacperhemperek/discord-go/store"
 	"github.com/kacperhemperek/discord-go/utils"
 	"net/http"
 )
@@ -15,7 +16,16 @@ var unauthorizedApiError = &utils.APIError{
 
 type AuthMiddleware = func(h utils.APIHandler) utils.APIHandler
 
-func NewAuthMiddleware() AuthMiddleware {
+var errRevokedSession = errors.New("refresh token session was revoked")
+
+// SessionVersionStore returns the session version refresh tokens of the user have to carry,
+// tokens issued before the version was bumped are rejected.
+type SessionVersionStore interface {
+	GetSessionVersion(userID int) (int, error)
+}
+
+// NewAuthMiddleware authenticates the request and marks the user as seen, lastSeen throttles the writes itself.
+func NewAuthMiddleware(lastSeen store.LastSeenTrackerInterface, sessions SessionVersionStore) AuthMiddleware {
 	return func(h utils.APIHandler) utils.APIHandler {
 		return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
 			accessToken, err := utils.GetAccessToken(r)
@@ -23,7 +33,7 @@ func NewAuthMiddleware() AuthMiddleware {
 				if !errors.Is(err, http.ErrNoCookie) {
 					return unauthorizedApiError
 				}
-				user, accessToken, newRefreshToken, err := createNewAccessTokenAndRefreshToken(r)
+				user, accessToken, newRefreshToken, err := createNewAccessTokenAndRefreshToken(r, sessions)
 
 				if err != nil {
 					return unauthorizedApiError
@@ -36,6 +46,7 @@ func NewAuthMiddleware() AuthMiddleware {
 				}
 
 				c.User = user
+				lastSeen.Touch(user.ID)
 
 				return h(w, r, c)
 			}
@@ -46,7 +57,7 @@ func NewAuthMiddleware() AuthMiddleware {
 				if !errors.Is(err, jwt.ErrTokenExpired) {
 					return unauthorizedApiError
 				}
-				refreshTokenUser, accessToken, newRefreshToken, err := createNewAccessTokenAndRefreshToken(r)
+				refreshTokenUser, accessToken, newRefreshToken, err := createNewAccessTokenAndRefreshToken(r, sessions)
 
 				if err != nil {
 					return unauthorizedApiError
@@ -58,13 +69,14 @@ func NewAuthMiddleware() AuthMiddleware {
 			}
 
 			c.User = accessTokenUser
+			lastSeen.Touch(accessTokenUser.ID)
 
 			return h(w, r, c)
 		}
 	}
 }
 
-func createNewAccessTokenAndRefreshToken(r *http.Request) (user *utils.JWTUser, accessToken, refreshToken string, err error) {
+func createNewAccessTokenAndRefreshToken(r *http.Request, sessions SessionVersionStore) (user *utils.JWTUser, accessToken, refreshToken string, err error) {
 	oldRefreshToken, err := utils.GetRefreshToken(r)
 	if err != nil {
 		return nil, "", "", err
@@ -76,12 +88,17 @@ func createNewAccessTokenAndRefreshToken(r *http.Request) (user *utils.JWTUser,
 		return nil, "", "", fmt.Errorf("error when parsing refresh token")
 	}
 
+	if err := checkSessionVersion(sessions, user); err != nil {
+		return nil, "", "", err
+	}
+
 	token := &utils.NewTokenProps{
-		ID:        user.ID,
-		Email:     user.Email,
-		Username:  user.Username,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:             user.ID,
+		Email:          user.Email,
+		Username:       user.Username,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+		SessionVersion: user.SessionVersion,
 	}
 
 	accessToken, err = utils.NewAccessToken(token)
@@ -98,3 +115,15 @@ func createNewAccessTokenAndRefreshToken(r *http.Request) (user *utils.JWTUser,
 
 	return user, accessToken, refreshToken, nil
 }
+
+// checkSessionVersion rejects refresh tokens issued before the user's sessions were revoked.
+func checkSessionVersion(sessions SessionVersionStore, user *utils.JWTUser) error {
+	version, err := sessions.GetSessionVersion(user.ID)
+	if err != nil {
+		return err
+	}
+	if version != user.SessionVersion {
+		return errRevokedSession
+	}
+	return nil
+}
@@ -172,6 +172,33 @@ func TestAuthMiddleware_AccessTokenMissingAndRefreshTokenValid(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_RefreshTokenSessionRevoked(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	authMiddleware := NewAuthMiddleware(&lastSeenTrackerMock{}, &sessionVersionStoreMock{version: 1})
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "", nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %s", err)
+	}
+	refreshToken, err := getTestToken(getJWTUser(time.Now().Add(time.Hour * 24 * 7)))
+	if err != nil {
+		t.Fatalf("Error creating refresh token: %s", err)
+	}
+	req.AddCookie(getTokenCookie(refreshToken, utils.RefreshTokenCookieName))
+
+	utils.HandlerFunc(authMiddleware(testHandler)).ServeHTTP(rr, req)
+
+	result := rr.Result()
+
+	if result.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, result.StatusCode)
+	}
+
+	if len(result.Cookies()) != 0 {
+		t.Errorf("Expected no cookies to be set, got %d", len(result.Cookies()))
+	}
+}
+
 func TestAuthMiddleware_AccessTokenMissingAndRefreshTokenExpired(t *testing.T) {
 	rr, err := setupAuthMiddlewareTestWithoutAccessToken(t, time.Now().Add(time.Hour*24*7))
 
@@ -253,7 +280,7 @@ func testHandler(w http.ResponseWriter, _ *http.Request, c *utils.APIContext) er
 
 func setupAuthMiddlewareTestWithTokens(t *testing.T, accessTokenExp, refreshTokenExp time.Time) (rr *httptest.ResponseRecorder, req *http.Request, err error) {
 	t.Setenv("JWT_SECRET", "test_secret")
-	authMiddleware := NewAuthMiddleware()
+	authMiddleware := NewAuthMiddleware(&lastSeenTrackerMock{}, &sessionVersionStoreMock{})
 	rr = httptest.NewRecorder()
 	req, err = http.NewRequest(http.MethodGet, "", nil)
 
@@ -288,7 +315,7 @@ func setupAuthMiddlewareTestWithTokens(t *testing.T, accessTokenExp, refreshToke
 
 func setupAuthMiddlewareTestWithoutAccessToken(t *testing.T, refreshTokenExp time.Time) (rr *httptest.ResponseRecorder, err error) {
 	t.Setenv("JWT_SECRET", "test_secret")
-	authMiddleware := NewAuthMiddleware()
+	authMiddleware := NewAuthMiddleware(&lastSeenTrackerMock{}, &sessionVersionStoreMock{})
 	rr = httptest.NewRecorder()
 	req, err := http.NewRequest(http.MethodGet, "", nil)
 
@@ -315,7 +342,7 @@ func setupAuthMiddlewareTestWithoutAccessToken(t *testing.T, refreshTokenExp tim
 
 func setupAuthMiddlewareTestWithoutTokens(t *testing.T) (rr *httptest.ResponseRecorder, err error) {
 	t.Setenv("JWT_SECRET", "test_secret")
-	authMiddleware := NewAuthMiddleware()
+	authMiddleware := NewAuthMiddleware(&lastSeenTrackerMock{}, &sessionVersionStoreMock{})
 	rr = httptest.NewRecorder()
 	req, err := http.NewRequest(http.MethodGet, "", nil)
 
@@ -372,3 +399,17 @@ func getJWTUser(expiresAt time.Time) *utils.JWTUser {
 		},
 	}
 }
+
+type lastSeenTrackerMock struct{}
+
+func (m *lastSeenTrackerMock) Touch(int) {}
+
+func (m *lastSeenTrackerMock) RecordLastSeen(int) {}
+
+type sessionVersionStoreMock struct {
+	version int
+}
+
+func (m *sessionVersionStoreMock) GetSessionVersion(int) (int, error) {
+	return m.version, nil
+}
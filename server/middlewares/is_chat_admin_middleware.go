@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/utils"
+	"net/http"
+)
+
+type IsChatAdminMiddleware = func(h utils.APIHandler) utils.APIHandler
+
+func NewIsChatAdminMiddleware(chatsStore store.ChatServiceInterface) IsChatAdminMiddleware {
+	return func(h utils.APIHandler) utils.APIHandler {
+		return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+			chatID, err := utils.GetIntParam(r, "chatID")
+			if err != nil {
+				return err
+			}
+
+			_, err = chatsStore.GetChatByID(chatID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return utils.NewNotFoundError("chat", "id", chatID)
+				}
+				return err
+			}
+			role, err := chatsStore.GetChatMemberRole(chatID, c.User.ID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return &utils.APIError{
+						Code:    http.StatusForbidden,
+						Message: "User is not a chat member",
+					}
+				}
+				return err
+			}
+			if !role.IsAdmin() {
+				return &utils.APIError{
+					Code:    http.StatusForbidden,
+					Message: "User is not a chat admin",
+				}
+			}
+			return h(w, r, c)
+		}
+	}
+}
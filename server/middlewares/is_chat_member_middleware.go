@@ -28,7 +28,7 @@ func NewIsChatMemberMiddleware(chatsStore store.ChatServiceInterface) IsChatMemb
 				return err
 			}
 			users, err := chatsStore.GetChatMembers(chat.ID)
-			userIsMember := slices.ContainsFunc(users, func(user *models.User) bool {
+			userIsMember := slices.ContainsFunc(users, func(user *models.PublicUser) bool {
 				return user.ID == c.User.ID
 			})
 			if !userIsMember {
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/utils"
+	"net/http"
+)
+
+type IsServerMemberMiddleware = func(h utils.APIHandler) utils.APIHandler
+
+func NewIsServerMemberMiddleware(serverStore store.ServerServiceInterface) IsServerMemberMiddleware {
+	return func(h utils.APIHandler) utils.APIHandler {
+		return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+			serverID, err := utils.GetIntParam(r, "serverID")
+			if err != nil {
+				return err
+			}
+
+			_, err = serverStore.GetServerByID(serverID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return utils.NewNotFoundError("server", "id", serverID)
+				}
+				return err
+			}
+			_, err = serverStore.GetServerMemberRole(serverID, c.User.ID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return &utils.APIError{
+						Code:    http.StatusForbidden,
+						Message: "User is not a server member",
+					}
+				}
+				return err
+			}
+			return h(w, r, c)
+		}
+	}
+}
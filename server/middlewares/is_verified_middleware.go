@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"github.com/kacperhemperek/discord-go/store"
+	"github.com/kacperhemperek/discord-go/utils"
+	"net/http"
+)
+
+type IsVerifiedMiddleware = func(h utils.APIHandler) utils.APIHandler
+
+// NewIsVerifiedMiddleware only lets through users who verified their email,
+// it has to be used after the auth middleware.
+func NewIsVerifiedMiddleware(userStore store.UserServiceInterface) IsVerifiedMiddleware {
+	return func(h utils.APIHandler) utils.APIHandler {
+		return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
+			user, err := userStore.GetUserByID(c.User.ID)
+			if err != nil {
+				return err
+			}
+			if !user.Active {
+				return &utils.APIError{
+					Code:    http.StatusForbidden,
+					Message: "Verify your email to do this",
+				}
+			}
+			return h(w, r, c)
+		}
+	}
+}
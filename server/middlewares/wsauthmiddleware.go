@@ -12,7 +12,7 @@ import (
 
 type WsAuthMiddleware = func(h utils.APIHandler) utils.APIHandler
 
-func NewWsAuthMiddleware() WsAuthMiddleware {
+func NewWsAuthMiddleware(sessions SessionVersionStore) WsAuthMiddleware {
 	return func(h utils.APIHandler) utils.APIHandler {
 		return func(w http.ResponseWriter, r *http.Request, c *utils.APIContext) error {
 			accessToken, err := getAccessTokenFromQueryParams(r)
@@ -26,7 +26,7 @@ func NewWsAuthMiddleware() WsAuthMiddleware {
 				if !errors.Is(err, jwt.ErrTokenExpired) {
 					return unauthorizedApiError
 				}
-				refreshTokenUser, accessToken, refreshToken, err := wsCreateNewAccessAndRefreshToken(r)
+				refreshTokenUser, accessToken, refreshToken, err := wsCreateNewAccessAndRefreshToken(r, sessions)
 				if err != nil {
 					return unauthorizedApiError
 				}
@@ -61,7 +61,7 @@ func NewWsAuthMiddleware() WsAuthMiddleware {
 	}
 }
 
-func wsCreateNewAccessAndRefreshToken(r *http.Request) (user *utils.JWTUser, accessToken, refreshToken string, err error) {
+func wsCreateNewAccessAndRefreshToken(r *http.Request, sessions SessionVersionStore) (user *utils.JWTUser, accessToken, refreshToken string, err error) {
 	oldRefreshToken, err := getRefreshTokenFromQueryParams(r)
 	if err != nil {
 		return nil, "", "", err
@@ -73,12 +73,17 @@ func wsCreateNewAccessAndRefreshToken(r *http.Request) (user *utils.JWTUser, acc
 		return nil, "", "", fmt.Errorf("error when parsing refresh token")
 	}
 
+	if err := checkSessionVersion(sessions, user); err != nil {
+		return nil, "", "", err
+	}
+
 	token := &utils.NewTokenProps{
-		ID:        user.ID,
-		Email:     user.Email,
-		Username:  user.Username,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:             user.ID,
+		Email:          user.Email,
+		Username:       user.Username,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+		SessionVersion: user.SessionVersion,
 	}
 
 	accessToken, err = utils.NewAccessToken(token)
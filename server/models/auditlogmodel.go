@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/kacperhemperek/discord-go/types"
+	"time"
+)
+
+type ChatAuditLogEntry struct {
+	ID           int                   `json:"id"`
+	ChatID       int                   `json:"chatId"`
+	ActorID      *int                  `json:"actorId"`
+	Action       types.ChatAuditAction `json:"action"`
+	TargetUserID *int                  `json:"targetUserId"`
+	Before       json.RawMessage       `json:"before"`
+	After        json.RawMessage       `json:"after"`
+	CreatedAt    time.Time             `json:"createdAt"`
+}
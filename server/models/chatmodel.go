@@ -6,11 +6,25 @@ import (
 )
 
 type Chat struct {
-	Name string         `json:"name"`
-	Type types.ChatType `json:"type"`
+	Name            string         `json:"name"`
+	Type            types.ChatType `json:"type"`
+	ServerID        *int           `json:"serverId"`
+	SlowModeSeconds int            `json:"slowModeSeconds"`
+	Description     *string        `json:"description"`
+	Topic           *string        `json:"topic"`
+	IconURL         *string        `json:"iconUrl"`
 	Base
 }
 
+// ChatUpdate holds the chat fields that were changed, fields left nil are not updated.
+// An empty description, topic or icon clears it.
+type ChatUpdate struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Topic       *string `json:"topic,omitempty"`
+	IconURL     *string `json:"iconUrl,omitempty"`
+}
+
 type UserToChat struct {
 	ChatID    string    `json:"chatId"`
 	UserID    string    `json:"userId"`
@@ -18,8 +32,15 @@ type UserToChat struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+type ChatSettings struct {
+	MutedUntil NullTime `json:"mutedUntil"`
+	Archived   bool     `json:"archived"`
+	Favourite  bool     `json:"favourite"`
+}
+
 type ChatWithMembers struct {
-	Members []*User `json:"members"`
+	Members  []*PublicUser `json:"members"`
+	Settings *ChatSettings `json:"settings"`
 	Chat
 }
 
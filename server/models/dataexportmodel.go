@@ -0,0 +1,17 @@
+package models
+
+import (
+	"github.com/kacperhemperek/discord-go/types"
+	"time"
+)
+
+// DataExport is a ZIP archive with everything stored about the user, it's built in the background
+// and can be downloaded once its status is ready.
+type DataExport struct {
+	ID          int                    `json:"id"`
+	UserID      int                    `json:"userId"`
+	Status      types.DataExportStatus `json:"status"`
+	FileName    *string                `json:"-"`
+	CreatedAt   time.Time              `json:"createdAt"`
+	CompletedAt NullTime               `json:"completedAt"`
+}
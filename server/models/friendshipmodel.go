@@ -15,14 +15,29 @@ type Friendship struct {
 }
 
 type FriendRequest struct {
-	ID              int       `json:"id"`
-	User            *User     `json:"user"`
-	Status          string    `json:"status"`
-	RequestedAt     time.Time `json:"requestedAt"`
-	StatusChangedAt NullTime  `json:"statusChangedAt"`
+	ID              int         `json:"id"`
+	User            *PublicUser `json:"user"`
+	Status          string      `json:"status"`
+	RequestedAt     time.Time   `json:"requestedAt"`
+	StatusChangedAt NullTime    `json:"statusChangedAt"`
 }
 
 type Friend struct {
 	AcceptedAt time.Time `json:"acceptedAt"`
-	User
+	PublicUser
+}
+
+// FriendSuggestion is a friend of the user's friends, ranked by how many friends and group chats they share.
+type FriendSuggestion struct {
+	User          *PublicUser `json:"user"`
+	MutualFriends int         `json:"mutualFriends"`
+	SharedChats   int         `json:"sharedChats"`
+}
+
+// FriendProfile is what the owner privately stores about a friend, only the owner can see it.
+type FriendProfile struct {
+	FriendID  int       `json:"friendId"`
+	Nickname  *string   `json:"nickname"`
+	Note      *string   `json:"note"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type ChatInvite struct {
+	Code      string   `json:"code"`
+	ChatID    int      `json:"chatId"`
+	CreatorID int      `json:"creatorId"`
+	MaxUses   *int     `json:"maxUses"`
+	Uses      int      `json:"uses"`
+	ExpiresAt NullTime `json:"expiresAt"`
+	RevokedAt NullTime `json:"revokedAt"`
+	Base
+}
+
+type ChatInvitePreview struct {
+	Code        string   `json:"code"`
+	ChatID      int      `json:"chatId"`
+	ChatName    string   `json:"chatName"`
+	MemberCount int      `json:"memberCount"`
+	ExpiresAt   NullTime `json:"expiresAt"`
+}
+
+type ChatInviteUse struct {
+	ID        int         `json:"id"`
+	InviteID  int         `json:"inviteId"`
+	User      *PublicUser `json:"user"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
@@ -1,11 +1,41 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+	"github.com/kacperhemperek/discord-go/types"
+)
+
 type Message struct {
-	Text string `json:"text"`
+	Text   string             `json:"text"`
+	Kind   types.MessageKind  `json:"kind"`
+	System *SystemMessageData `json:"system"`
 	Base
 }
 
 type MessageWithUser struct {
-	User *User `json:"user"`
+	User *PublicUser `json:"user"`
+	// FromBlockedUser is set when the user reading the message has blocked its sender
+	FromBlockedUser bool `json:"fromBlockedUser"`
 	Message
 }
+
+// SystemMessageData is the structured payload of a system message, the user of the message
+// is the one who caused the event and only the fields relevant to the type are set.
+type SystemMessageData struct {
+	Type    string  `json:"type"`
+	Name    *string `json:"name,omitempty"`
+	OldName *string `json:"oldName,omitempty"`
+	UserIDs []int   `json:"userIds,omitempty"`
+}
+
+func (d *SystemMessageData) Scan(value any) error {
+	switch val := value.(type) {
+	case []byte:
+		return json.Unmarshal(val, d)
+	case string:
+		return json.Unmarshal([]byte(val), d)
+	default:
+		return errors.New("invalid system message data")
+	}
+}
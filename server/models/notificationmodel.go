@@ -20,7 +20,8 @@ type NotificationDTO = struct {
 }
 
 type FriendRequestNotificationData struct {
-	TestValue string `json:"testValue" validate:"required"`
+	FriendshipID int         `json:"friendshipId" validate:"required"`
+	Inviter      *PublicUser `json:"inviter" validate:"required"`
 }
 
 type FriendRequestNotification struct {
@@ -47,3 +48,13 @@ type NewMessageNotification struct {
 	BaseNotification
 	Data NewMessageNotificationData `json:"data"`
 }
+
+type FriendRequestAcceptedNotificationData struct {
+	FriendshipID int         `json:"friendshipId" validate:"required"`
+	Friend       *PublicUser `json:"friend" validate:"required"`
+}
+
+type FriendRequestAcceptedNotification struct {
+	BaseNotification
+	Data *FriendRequestAcceptedNotificationData `json:"data"`
+}
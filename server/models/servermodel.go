@@ -0,0 +1,20 @@
+package models
+
+import "github.com/kacperhemperek/discord-go/types"
+
+type Server struct {
+	Name    string  `json:"name"`
+	IconURL *string `json:"iconUrl"`
+	OwnerID int     `json:"ownerId"`
+	Base
+}
+
+type ServerWithChannels struct {
+	Channels []*Chat `json:"channels"`
+	Server
+}
+
+type ServerMember struct {
+	Role types.ChatRole `json:"role"`
+	PublicUser
+}
@@ -1,9 +1,92 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kacperhemperek/discord-go/types"
+)
+
 type User struct {
 	Username string `json:"username"`
+	Handle   string `json:"handle"`
+	Profile
 	Password string `json:"-"`
 	Email    string `json:"email"`
 	Active   bool   `json:"active"`
 	Base
 }
+
+// PublicUser is the part of the user that can be shown to other users, it never contains the email.
+type PublicUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Handle   string `json:"handle"`
+	Profile
+	// Nickname is the one the viewing user gave this user, it's only loaded for friend and chat member lists.
+	Nickname *string `json:"nickname,omitempty"`
+	// Status is the user's presence, it's only loaded for the friend list.
+	Status *types.PresenceStatus `json:"status,omitempty"`
+	// CustomStatus is only loaded for friend and chat member lists.
+	CustomStatus *CustomStatus `json:"customStatus,omitempty"`
+}
+
+func (u *User) Public() *PublicUser {
+	return &PublicUser{
+		ID:       u.ID,
+		Username: u.Username,
+		Handle:   u.Handle,
+		Profile:  u.Profile,
+	}
+}
+
+// Profile holds what the user shows about themselves to others.
+type Profile struct {
+	DisplayName *string `json:"displayName"`
+	Bio         *string `json:"bio"`
+	Avatar      *Avatar `json:"avatar"`
+	BannerColor *string `json:"bannerColor"`
+}
+
+// ProfileUpdate holds the changed profile fields, an empty string clears the field.
+type ProfileUpdate struct {
+	DisplayName *string `json:"displayName,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	BannerColor *string `json:"bannerColor,omitempty"`
+}
+
+// Avatar holds urls of the uploaded avatar resized to each of the standard sizes,
+// it is stored as json in the users table.
+type Avatar struct {
+	Small  string `json:"small"`
+	Medium string `json:"medium"`
+	Large  string `json:"large"`
+}
+
+func (a *Avatar) URLs() []string {
+	return []string{a.Small, a.Medium, a.Large}
+}
+
+func (a *Avatar) Scan(value any) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, a)
+	case string:
+		return json.Unmarshal([]byte(data), a)
+	default:
+		return fmt.Errorf("cannot scan %T into avatar", value)
+	}
+}
+
+type PrivacySettings struct {
+	FriendRequests types.FriendRequestPrivacy `json:"friendRequests"`
+	// ShowLastSeen lets friends see when the user was last active.
+	ShowLastSeen bool `json:"showLastSeen"`
+}
+
+// CustomStatus is the status message the user set for themselves, it is cleared once ExpiresAt passes.
+type CustomStatus struct {
+	Text         *string  `json:"text"`
+	Emoji        *string  `json:"emoji"`
+	ExpiresAt    NullTime `json:"expiresAt"`
+	DoNotDisturb bool     `json:"doNotDisturb"`
+}
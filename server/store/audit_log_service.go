@@ -0,0 +1,128 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/types"
+	"github.com/kacperhemperek/discord-go/utils"
+	"time"
+)
+
+const DefaultAuditLogLimit = 50
+
+type AuditLogServiceInterface interface {
+	GetChatAuditLog(chatID int, filters *ChatAuditLogFilters) ([]*models.ChatAuditLogEntry, error)
+}
+
+// ChatAuditLogInput describes a single administrative action, Before and After are stored as json
+// and should only contain the fields the action changed. Store methods making audited changes take it
+// and record it in the same transaction as the change.
+type ChatAuditLogInput struct {
+	ChatID       int
+	ActorID      int
+	Action       types.ChatAuditAction
+	TargetUserID *int
+	Before       any
+	After        any
+}
+
+// ChatAuditLogFilters narrows down the audit log, entries are returned newest first
+// and BeforeID is the id of the last entry from the previous page.
+type ChatAuditLogFilters struct {
+	Action   *types.ChatAuditAction
+	ActorID  *int
+	BeforeID *int
+	Limit    *LimitFilter
+}
+
+type AuditLogService struct {
+	db *Database
+}
+
+// logChatAction stores the audit entry in the transaction of the action it describes,
+// so an action can't take effect without its entry. A nil entry is not recorded.
+func logChatAction(tx *sql.Tx, entry *ChatAuditLogInput) error {
+	if entry == nil {
+		return nil
+	}
+	before, err := marshalAuditState(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalAuditState(entry.After)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO chat_audit_log (chat_id, actor_id, action, target_user_id, before, after) VALUES ($1, $2, $3, $4, $5, $6);",
+		entry.ChatID,
+		entry.ActorID,
+		entry.Action.String(),
+		entry.TargetUserID,
+		before,
+		after,
+	)
+	return err
+}
+
+func (s *AuditLogService) GetChatAuditLog(chatID int, filters *ChatAuditLogFilters) ([]*models.ChatAuditLogEntry, error) {
+	defer utils.LogServiceCall("AuditLogService", "GetChatAuditLog", time.Now())
+	where := []string{"chat_id = @chat_id"}
+	args := pgx.NamedArgs{
+		"chat_id": chatID,
+	}
+	if v := filters.Action; v != nil {
+		where = append(where, "action = @action")
+		args["action"] = v.String()
+	}
+	if v := filters.ActorID; v != nil {
+		where = append(where, "actor_id = @actor_id")
+		args["actor_id"] = *v
+	}
+	if v := filters.BeforeID; v != nil {
+		where = append(where, "id < @before_id")
+		args["before_id"] = *v
+	}
+	limit := DefaultAuditLogLimit
+	if v := filters.Limit; v != nil {
+		limit = *v
+	}
+	rows, err := s.db.Query(
+		"SELECT id, chat_id, actor_id, action, target_user_id, before, after, created_at FROM chat_audit_log "+
+			whereSQL(where)+
+			fmt.Sprintf(" ORDER BY id DESC LIMIT %d;", limit),
+		args,
+	)
+	entries := make([]*models.ChatAuditLogEntry, 0)
+	if err != nil {
+		return entries, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		entry, err := scanChatAuditLogEntry(rows)
+		if err != nil {
+			return make([]*models.ChatAuditLogEntry, 0), err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func marshalAuditState(state any) (*string, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	str := string(data)
+	return &str, nil
+}
+
+func NewAuditLogService(db *Database) *AuditLogService {
+	return &AuditLogService{db: db}
+}
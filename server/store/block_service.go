@@ -0,0 +1,100 @@
+package store
+
+import (
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/utils"
+	"time"
+)
+
+type BlockServiceInterface interface {
+	BlockUser(blockerID, blockedID int) error
+	UnblockUser(blockerID, blockedID int) error
+	IsBlockedEitherWay(userOneID, userTwoID int) (bool, error)
+	GetBlockedUsers(userID int) ([]*models.PublicUser, error)
+	GetBlockerIDs(blockedID int) ([]int, error)
+}
+
+type BlockService struct {
+	db *Database
+}
+
+func (s *BlockService) BlockUser(blockerID, blockedID int) error {
+	defer utils.LogServiceCall("BlockService", "BlockUser", time.Now())
+	_, err := s.db.Exec(
+		"INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT (blocker_id, blocked_id) DO NOTHING;",
+		blockerID,
+		blockedID,
+	)
+	return err
+}
+
+func (s *BlockService) UnblockUser(blockerID, blockedID int) error {
+	defer utils.LogServiceCall("BlockService", "UnblockUser", time.Now())
+	_, err := s.db.Exec(
+		"DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2;",
+		blockerID,
+		blockedID,
+	)
+	return err
+}
+
+// IsBlockedEitherWay reports whether any of the users has blocked the other one.
+func (s *BlockService) IsBlockedEitherWay(userOneID, userTwoID int) (bool, error) {
+	defer utils.LogServiceCall("BlockService", "IsBlockedEitherWay", time.Now())
+	var blocked bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM user_blocks
+				WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		);`,
+		userOneID,
+		userTwoID,
+	).Scan(&blocked)
+	return blocked, err
+}
+
+func (s *BlockService) GetBlockedUsers(userID int) ([]*models.PublicUser, error) {
+	defer utils.LogServiceCall("BlockService", "GetBlockedUsers", time.Now())
+	rows, err := s.db.Query(`
+		SELECT u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color
+			FROM user_blocks b JOIN users u ON u.id = b.blocked_id
+			WHERE b.blocker_id = $1 ORDER BY b.created_at DESC;`,
+		userID,
+	)
+	users := make([]*models.PublicUser, 0)
+	if err != nil {
+		return users, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		user, err := scanPublicUser(rows)
+		if err != nil {
+			return make([]*models.PublicUser, 0), err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// GetBlockerIDs returns ids of the users who blocked the given user.
+func (s *BlockService) GetBlockerIDs(blockedID int) ([]int, error) {
+	defer utils.LogServiceCall("BlockService", "GetBlockerIDs", time.Now())
+	rows, err := s.db.Query("SELECT blocker_id FROM user_blocks WHERE blocked_id = $1;", blockedID)
+	blockerIDs := make([]int, 0)
+	if err != nil {
+		return blockerIDs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var blockerID int
+		if err := rows.Scan(&blockerID); err != nil {
+			return make([]int, 0), err
+		}
+		blockerIDs = append(blockerIDs, blockerID)
+	}
+	return blockerIDs, rows.Err()
+}
+
+func NewBlockService(db *Database) *BlockService {
+	return &BlockService{db: db}
+}
@@ -2,10 +2,13 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/jackc/pgx/v5"
 	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/types"
 	"github.com/kacperhemperek/discord-go/utils"
+	"slices"
 	"strings"
 	"time"
 )
@@ -17,19 +20,29 @@ type ChatService struct {
 type ChatServiceInterface interface {
 	GetPrivateChatByUserIDs(int, int) (*models.Chat, error)
 	CreatePrivateChatWithUsers(int, int) (*models.Chat, error)
-	GetUsersChatsWithMembers(userID int) ([]*models.ChatWithMembers, error)
-	CreateGroupChat(chatName string, userIDs []int) (*models.Chat, error)
+	GetUsersChatsWithMembers(userID int, includeArchived bool) ([]*models.ChatWithMembers, error)
+	CreateGroupChat(chatName string, ownerID int, userIDs []int) (*models.Chat, error)
 	GetChatByID(chatID int) (*models.Chat, error)
-	EnrichChatWithMessages(chat *models.Chat) (*models.ChatWithMessages, error)
-	GetChatMembersExcluding(chatID int, excludeUserIDs []int) ([]*models.User, error)
-	GetChatMembers(chatID int) ([]*models.User, error)
-	UpdateChatName(chatID int, newName string) error
+	EnrichChatWithMessages(chat *models.Chat, viewerID int) (*models.ChatWithMessages, error)
+	GetChatMembersExcluding(chatID int, excludeUserIDs []int) ([]*models.PublicUser, error)
+	GetChatMembers(chatID int) ([]*models.PublicUser, error)
+	GetChatMembersWithNicknames(chatID, viewerID int) ([]*models.PublicUser, error)
+	UpdateChat(chatID, actorID int, update *models.ChatUpdate, audit *ChatAuditLogInput) (*models.Chat, error)
+	GetChatMemberRole(chatID, userID int) (types.ChatRole, error)
+	UpdateChatSettings(chatID, userID int, settings *models.ChatSettings) (*models.ChatSettings, error)
+	GetMutedMemberIDs(chatID int) ([]int, error)
+	UpdateChatSlowMode(chatID, seconds int, audit *ChatAuditLogInput) error
+	UpdateChatMemberRole(chatID, userID int, role types.ChatRole, audit *ChatAuditLogInput) error
+	AddChatMembers(chatID, actorID int, userIDs []int) ([]int, error)
+	RemoveChatMember(chatID, actorID, userID int, audit *ChatAuditLogInput) error
+	DeleteChat(chatID int) error
+	TransferChatOwnership(chatID, ownerID, newOwnerID int, audit *ChatAuditLogInput) error
 }
 
 func (s *ChatService) GetPrivateChatByUserIDs(userOneID, userTwoID int) (*models.Chat, error) {
 	defer utils.LogServiceCall("ChatService", "GetPrivateChatByUserIDs", time.Now())
 	row := s.db.QueryRow(
-		`SELECT chats.id, chats.name, chats.type, chats.created_at, chats.updated_at
+		`SELECT chats.id, chats.name, chats.type, chats.server_id, chats.slow_mode_seconds, chats.description, chats.topic, chats.icon_url, chats.created_at, chats.updated_at
 			FROM chats
     		JOIN public.chat_to_user ctu1 on chats.id = ctu1.chat_id AND ctu1.user_id = $1
     		JOIN public.chat_to_user ctu2 on chats.id = ctu1.chat_id AND ctu2.user_id = $2`,
@@ -53,7 +66,7 @@ func (s *ChatService) CreatePrivateChatWithUsers(userOneID, userTwoID int) (*mod
 		return nil, err
 	}
 	rows, err := tx.Query(
-		"INSERT INTO chats (type, name) VALUES('private', 'privchat') RETURNING id, name, type, created_at, updated_at;",
+		"INSERT INTO chats (type, name) VALUES('private', 'privchat') RETURNING id, name, type, server_id, slow_mode_seconds, description, topic, icon_url, created_at, updated_at;",
 	)
 	if err != nil {
 		rollbackErr := tx.Rollback()
@@ -93,16 +106,30 @@ func (s *ChatService) CreatePrivateChatWithUsers(userOneID, userTwoID int) (*mod
 	return chat, nil
 }
 
-func (s *ChatService) GetUsersChatsWithMembers(userID int) ([]*models.ChatWithMembers, error) {
+// GetUsersChatsWithMembers returns user's chats with favourites first, archived chats are only returned when includeArchived is set.
+func (s *ChatService) GetUsersChatsWithMembers(userID int, includeArchived bool) ([]*models.ChatWithMembers, error) {
 	tx, err := s.db.Begin()
 	defer func(now time.Time) {
 		utils.LogServiceCall("ChatService", "GetUsersChatsWithMembers", now)
 		rollback(tx)
 	}(time.Now())
 
-	rows, err := s.db.Query(
-		"SELECT chats.id, chats.name, chats.type, chats.created_at, chats.updated_at FROM chats JOIN chat_to_user member on user_id=$1 WHERE chats.id = member.chat_id ORDER BY chats.updated_at DESC;",
-		userID,
+	where := []string{"member.user_id = @user_id"}
+	if !includeArchived {
+		where = append(where, "NOT COALESCE(settings.archived, false)")
+	}
+
+	rows, err := s.db.Query(`
+		SELECT chats.id, chats.name, chats.type, chats.server_id, chats.slow_mode_seconds, chats.description, chats.topic, chats.icon_url, chats.created_at, chats.updated_at,
+		       settings.muted_until, COALESCE(settings.archived, false), COALESCE(settings.favourite, false)
+		FROM chats
+		JOIN chat_to_user member ON chats.id = member.chat_id
+		LEFT JOIN chat_user_settings settings ON settings.chat_id = chats.id AND settings.user_id = member.user_id `+
+		whereSQL(where)+
+		" ORDER BY COALESCE(settings.favourite, false) DESC, chats.updated_at DESC;",
+		pgx.NamedArgs{
+			"user_id": userID,
+		},
 	)
 	if err != nil {
 
@@ -112,7 +139,7 @@ func (s *ChatService) GetUsersChatsWithMembers(userID int) ([]*models.ChatWithMe
 	chats := make([]*models.ChatWithMembers, 0)
 
 	for rows.Next() {
-		chat, err := scanChat(rows)
+		chat, settings, err := scanChatWithSettings(rows)
 
 		if err != nil {
 			return make([]*models.ChatWithMembers, 0), err
@@ -121,14 +148,16 @@ func (s *ChatService) GetUsersChatsWithMembers(userID int) ([]*models.ChatWithMe
 		members, err := s.getChatMembers(tx, &GetMembersFilters{
 			ExcludedIDs: make([]int, 0),
 			ChatID:      chat.ID,
+			ViewerID:    &userID,
 		})
 		if err != nil {
 			return make([]*models.ChatWithMembers, 0), err
 		}
 
 		chatWithMembers := &models.ChatWithMembers{
-			Members: members,
-			Chat:    *chat,
+			Members:  members,
+			Settings: settings,
+			Chat:     *chat,
 		}
 
 		chats = append(chats, chatWithMembers)
@@ -137,13 +166,13 @@ func (s *ChatService) GetUsersChatsWithMembers(userID int) ([]*models.ChatWithMe
 	return chats, nil
 }
 
-func (s *ChatService) CreateGroupChat(chatName string, userIDs []int) (*models.Chat, error) {
+func (s *ChatService) CreateGroupChat(chatName string, ownerID int, userIDs []int) (*models.Chat, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	row := tx.QueryRow(
-		"INSERT INTO chats (name, type) VALUES ($1, 'group') RETURNING id, name, type,created_at, updated_at",
+		"INSERT INTO chats (name, type) VALUES ($1, 'group') RETURNING id, name, type, server_id, slow_mode_seconds, description, topic, icon_url, created_at, updated_at",
 		chatName,
 	)
 	chat, err := scanChat(row)
@@ -156,9 +185,13 @@ func (s *ChatService) CreateGroupChat(chatName string, userIDs []int) (*models.C
 	}
 	userVals := make([]string, len(userIDs))
 	for i, userID := range userIDs {
-		userVals[i] = fmt.Sprintf("(%d, %d)", chat.ID, userID)
+		role := types.MemberChatRole
+		if userID == ownerID {
+			role = types.OwnerChatRole
+		}
+		userVals[i] = fmt.Sprintf("(%d, %d, '%s')", chat.ID, userID, role.String())
 	}
-	query := fmt.Sprintf("INSERT INTO chat_to_user (chat_id, user_id) VALUES %s", strings.Join(userVals, ","))
+	query := fmt.Sprintf("INSERT INTO chat_to_user (chat_id, user_id, role) VALUES %s", strings.Join(userVals, ","))
 	_, err = tx.Exec(query)
 	if err != nil {
 		rollbackErr := tx.Rollback()
@@ -167,6 +200,21 @@ func (s *ChatService) CreateGroupChat(chatName string, userIDs []int) (*models.C
 		}
 		return nil, err
 	}
+	memberIDs := slices.DeleteFunc(slices.Clone(userIDs), func(userID int) bool {
+		return userID == ownerID
+	})
+	err = createSystemMessage(tx, chat.ID, ownerID, &models.SystemMessageData{
+		Type:    types.ChatCreatedSystemMessage,
+		Name:    &chatName,
+		UserIDs: memberIDs,
+	})
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			fmt.Println("Error rolling back transaction")
+		}
+		return nil, err
+	}
 	err = tx.Commit()
 	if err != nil {
 		return nil, err
@@ -176,28 +224,35 @@ func (s *ChatService) CreateGroupChat(chatName string, userIDs []int) (*models.C
 
 func (s *ChatService) GetChatByID(chatID int) (*models.Chat, error) {
 	row := s.db.QueryRow(
-		"SELECT id, name, type,created_at, updated_at FROM chats WHERE id = $1",
+		"SELECT id, name, type, server_id, slow_mode_seconds, description, topic, icon_url, created_at, updated_at FROM chats WHERE id = $1",
 		chatID,
 	)
 	return scanChat(row)
 }
 
-func (s *ChatService) EnrichChatWithMessages(chat *models.Chat) (*models.ChatWithMessages, error) {
+// EnrichChatWithMessages loads the chat history, messages from users the viewer has blocked are flagged.
+func (s *ChatService) EnrichChatWithMessages(chat *models.Chat, viewerID int) (*models.ChatWithMessages, error) {
 	rows, err := s.db.Query(`
 		SELECT m.id,
 		       m.text, 
+		       m.kind,
+		       m.system_data,
 		       m.created_at,
 		       m.updated_at, 
 		       u.id, 
 		       u.username,
-		       u.email,
-		       u.active,
-		       u.password,
-		       u.created_at, 
-		       u.updated_at 
-		FROM messages m JOIN users u on u.id = m.sender_id  WHERE m.chat_id = $1 
+		       u.handle,
+		       u.display_name,
+		       u.bio,
+		       u.avatar,
+		       u.banner_color,
+		       b.blocked_id IS NOT NULL
+		FROM messages m JOIN users u on u.id = m.sender_id
+		LEFT JOIN user_blocks b ON b.blocker_id = $2 AND b.blocked_id = m.sender_id
+		WHERE m.chat_id = $1 
 		ORDER BY m.created_at DESC`,
 		chat.ID,
+		viewerID,
 	)
 	if err != nil {
 		return nil, err
@@ -217,7 +272,7 @@ func (s *ChatService) EnrichChatWithMessages(chat *models.Chat) (*models.ChatWit
 	return cwm, nil
 }
 
-func (s *ChatService) GetChatMembers(chatID int) ([]*models.User, error) {
+func (s *ChatService) GetChatMembers(chatID int) ([]*models.PublicUser, error) {
 	tx, err := s.db.Begin()
 
 	defer func(now time.Time) {
@@ -226,15 +281,34 @@ func (s *ChatService) GetChatMembers(chatID int) ([]*models.User, error) {
 	}(time.Now())
 
 	if err != nil {
-		return make([]*models.User, 0), err
+		return make([]*models.PublicUser, 0), err
+	}
+	return s.getChatMembers(tx, &GetMembersFilters{
+		ExcludedIDs: make([]int, 0),
+		ChatID:      chatID,
+	})
+}
+
+// GetChatMembersWithNicknames returns chat members with the nicknames the viewer gave them.
+func (s *ChatService) GetChatMembersWithNicknames(chatID, viewerID int) ([]*models.PublicUser, error) {
+	tx, err := s.db.Begin()
+
+	defer func(now time.Time) {
+		utils.LogServiceCall("ChatService", "GetChatMembersWithNicknames", now)
+		rollback(tx)
+	}(time.Now())
+
+	if err != nil {
+		return make([]*models.PublicUser, 0), err
 	}
 	return s.getChatMembers(tx, &GetMembersFilters{
 		ExcludedIDs: make([]int, 0),
 		ChatID:      chatID,
+		ViewerID:    &viewerID,
 	})
 }
 
-func (s *ChatService) GetChatMembersExcluding(chatID int, excludeUserIDs []int) ([]*models.User, error) {
+func (s *ChatService) GetChatMembersExcluding(chatID int, excludeUserIDs []int) ([]*models.PublicUser, error) {
 	tx, err := s.db.Begin()
 
 	defer func(now time.Time) {
@@ -242,7 +316,7 @@ func (s *ChatService) GetChatMembersExcluding(chatID int, excludeUserIDs []int)
 		rollback(tx)
 	}(time.Now())
 	if err != nil {
-		return make([]*models.User, 0), err
+		return make([]*models.PublicUser, 0), err
 	}
 	members, err := s.getChatMembers(tx, &GetMembersFilters{
 		ExcludedIDs: excludeUserIDs,
@@ -250,23 +324,362 @@ func (s *ChatService) GetChatMembersExcluding(chatID int, excludeUserIDs []int)
 	})
 
 	if err != nil {
-		return make([]*models.User, 0), err
+		return make([]*models.PublicUser, 0), err
 	}
 
 	err = tx.Commit()
 
 	if err != nil {
-		return make([]*models.User, 0), err
+		return make([]*models.PublicUser, 0), err
 	}
 
 	return members, nil
 }
 
-func (s *ChatService) UpdateChatName(chatID int, newName string) error {
-	_, err := s.db.Exec(
-		"UPDATE chats SET name = $1, updated_at=current_timestamp WHERE id = $2",
-		newName,
+// UpdateChat updates only the fields set in the update and returns the updated chat,
+// empty description, topic or icon url are stored as NULL. Renaming the chat leaves a system message in it.
+func (s *ChatService) UpdateChat(chatID, actorID int, update *models.ChatUpdate, audit *ChatAuditLogInput) (*models.Chat, error) {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ChatService", "UpdateChat", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var oldName string
+	if err := tx.QueryRow("SELECT name FROM chats WHERE id = $1 FOR UPDATE;", chatID).Scan(&oldName); err != nil {
+		return nil, err
+	}
+	sets := []string{"updated_at = CURRENT_TIMESTAMP"}
+	args := pgx.NamedArgs{"chat_id": chatID}
+	if update.Name != nil {
+		sets = append(sets, "name = @name")
+		args["name"] = *update.Name
+	}
+	if update.Description != nil {
+		sets = append(sets, "description = NULLIF(@description, '')")
+		args["description"] = *update.Description
+	}
+	if update.Topic != nil {
+		sets = append(sets, "topic = NULLIF(@topic, '')")
+		args["topic"] = *update.Topic
+	}
+	if update.IconURL != nil {
+		sets = append(sets, "icon_url = NULLIF(@icon_url, '')")
+		args["icon_url"] = *update.IconURL
+	}
+	row := tx.QueryRow(
+		"UPDATE chats SET "+strings.Join(sets, ", ")+" WHERE id = @chat_id RETURNING id, name, type, server_id, slow_mode_seconds, description, topic, icon_url, created_at, updated_at;",
+		args,
+	)
+	chat, err := scanChat(row)
+	if err != nil {
+		return nil, err
+	}
+	if update.Name != nil && *update.Name != oldName {
+		err = createSystemMessage(tx, chatID, actorID, &models.SystemMessageData{
+			Type:    types.ChatRenamedSystemMessage,
+			Name:    update.Name,
+			OldName: &oldName,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := logChatAction(tx, audit); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return chat, nil
+}
+
+func (s *ChatService) GetChatMemberRole(chatID, userID int) (types.ChatRole, error) {
+	defer utils.LogServiceCall("ChatService", "GetChatMemberRole", time.Now())
+	var role types.ChatRole
+	err := s.db.QueryRow(
+		"SELECT role FROM chat_members WHERE chat_id = $1 AND user_id = $2",
+		chatID,
+		userID,
+	).Scan(&role)
+	return role, err
+}
+
+func (s *ChatService) UpdateChatSettings(chatID, userID int, settings *models.ChatSettings) (*models.ChatSettings, error) {
+	defer utils.LogServiceCall("ChatService", "UpdateChatSettings", time.Now())
+	var mutedUntil *time.Time
+	if settings.MutedUntil.Valid {
+		mutedUntil = &settings.MutedUntil.Time
+	}
+	row := s.db.QueryRow(`
+		INSERT INTO chat_user_settings (chat_id, user_id, muted_until, archived, favourite)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (chat_id, user_id) DO UPDATE
+			SET muted_until = excluded.muted_until,
+			    archived = excluded.archived,
+			    favourite = excluded.favourite,
+			    updated_at = CURRENT_TIMESTAMP
+			RETURNING muted_until, archived, favourite;`,
+		chatID,
+		userID,
+		mutedUntil,
+		settings.Archived,
+		settings.Favourite,
+	)
+	return scanChatSettings(row)
+}
+
+// GetMutedMemberIDs returns IDs of chat members who currently have the chat muted.
+func (s *ChatService) GetMutedMemberIDs(chatID int) ([]int, error) {
+	defer utils.LogServiceCall("ChatService", "GetMutedMemberIDs", time.Now())
+	rows, err := s.db.Query(
+		"SELECT user_id FROM chat_user_settings WHERE chat_id = $1 AND muted_until > $2;",
 		chatID,
+		time.Now().UTC(),
+	)
+	userIDs := make([]int, 0)
+	if err != nil {
+		return userIDs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		userID, err := scanID(rows)
+		if err != nil {
+			return make([]int, 0), err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func (s *ChatService) UpdateChatSlowMode(chatID, seconds int, audit *ChatAuditLogInput) error {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ChatService", "UpdateChatSlowMode", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE chats SET slow_mode_seconds = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;",
+		seconds,
+		chatID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := logChatAction(tx, audit); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *ChatService) UpdateChatMemberRole(chatID, userID int, role types.ChatRole, audit *ChatAuditLogInput) error {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ChatService", "UpdateChatMemberRole", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE chat_to_user SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE chat_id = $2 AND user_id = $3;",
+		role.String(),
+		chatID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := logChatAction(tx, audit); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddChatMembers adds users to the chat as members and returns ids of the users that were not in it yet,
+// the added users are announced with a system message and recorded in the audit log.
+func (s *ChatService) AddChatMembers(chatID, actorID int, userIDs []int) ([]int, error) {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ChatService", "AddChatMembers", now)
+		rollback(tx)
+	}(time.Now())
+	addedIDs := make([]int, 0)
+	if err != nil {
+		return addedIDs, err
+	}
+	if len(userIDs) == 0 {
+		return addedIDs, nil
+	}
+	values := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		values[i] = fmt.Sprintf("(%d, %d)", chatID, userID)
+	}
+	rows, err := tx.Query(
+		"INSERT INTO chat_to_user (chat_id, user_id) VALUES " + strings.Join(values, ",") + " ON CONFLICT (chat_id, user_id) DO NOTHING RETURNING user_id;",
+	)
+	if err != nil {
+		return addedIDs, err
+	}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return make([]int, 0), err
+		}
+		addedIDs = append(addedIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return make([]int, 0), err
+	}
+	if len(addedIDs) == 0 {
+		return addedIDs, nil
+	}
+	err = createSystemMessage(tx, chatID, actorID, &models.SystemMessageData{
+		Type:    types.MemberAddedSystemMessage,
+		UserIDs: addedIDs,
+	})
+	if err != nil {
+		return make([]int, 0), err
+	}
+	err = logChatAction(tx, &ChatAuditLogInput{
+		ChatID:  chatID,
+		ActorID: actorID,
+		Action:  types.MembersAddedAuditAction,
+		After:   map[string][]int{"userIds": addedIDs},
+	})
+	if err != nil {
+		return make([]int, 0), err
+	}
+	if err := tx.Commit(); err != nil {
+		return make([]int, 0), err
+	}
+	return addedIDs, nil
+}
+
+// RemoveChatMember removes the user from the chat, it is recorded as the user leaving
+// when actorID is the removed user and as a removal by the actor otherwise.
+func (s *ChatService) RemoveChatMember(chatID, actorID, userID int, audit *ChatAuditLogInput) error {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ChatService", "RemoveChatMember", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		"DELETE FROM chat_to_user WHERE chat_id = $1 AND user_id = $2;",
+		chatID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	// a concurrent kick or leave already removed the member, it must not be announced and audited twice
+	if removed, err := res.RowsAffected(); err != nil {
+		return err
+	} else if removed == 0 {
+		return sql.ErrNoRows
+	}
+	systemMessage := &models.SystemMessageData{
+		Type:    types.MemberRemovedSystemMessage,
+		UserIDs: []int{userID},
+	}
+	if actorID == userID {
+		systemMessage.Type = types.MemberLeftSystemMessage
+	}
+	if err := createSystemMessage(tx, chatID, actorID, systemMessage); err != nil {
+		return err
+	}
+	if err := logChatAction(tx, audit); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteChat removes the chat together with new message notifications pointing at it,
+// messages and memberships are removed by the cascade on their foreign keys.
+func (s *ChatService) DeleteChat(chatID int) error {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ChatService", "DeleteChat", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return err
+	}
+	nmn := types.NewMessageNotification
+	_, err = tx.Exec(
+		"DELETE FROM notifications WHERE type = $1 AND (data->>'chatId')::int = $2;",
+		nmn.String(),
+		chatID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM chats WHERE id = $1;", chatID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TransferChatOwnership makes newOwnerID the owner of the chat, the previous owner stays in the chat as an admin.
+func (s *ChatService) TransferChatOwnership(chatID, ownerID, newOwnerID int, audit *ChatAuditLogInput) error {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ChatService", "TransferChatOwnership", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return err
+	}
+	owner := types.OwnerChatRole
+	admin := types.AdminChatRole
+	_, err = tx.Exec(
+		"UPDATE chat_to_user SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE chat_id = $2 AND user_id = $3;",
+		admin.String(),
+		chatID,
+		ownerID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE chat_to_user SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE chat_id = $2 AND user_id = $3;",
+		owner.String(),
+		chatID,
+		newOwnerID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := logChatAction(tx, audit); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// createSystemMessage stores a system message in the chat on behalf of the user who caused the event.
+func createSystemMessage(tx *sql.Tx, chatID, actorID int, data *models.SystemMessageData) error {
+	systemData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	system := types.SystemMessage
+	_, err = tx.Exec(
+		"INSERT INTO messages (text, sender_id, chat_id, kind, system_data) VALUES ('', $1, $2, $3, $4);",
+		actorID,
+		chatID,
+		system.String(),
+		string(systemData),
 	)
 	return err
 }
@@ -275,12 +688,13 @@ func (s *ChatService) getChats(tx *sql.Tx, filter *GetChatsFilters) ([]*models.C
 	return nil, nil
 }
 
-func (s *ChatService) getChatMembers(tx *sql.Tx, filter *GetMembersFilters) ([]*models.User, error) {
+func (s *ChatService) getChatMembers(tx *sql.Tx, filter *GetMembersFilters) ([]*models.PublicUser, error) {
 	where := []string{
 		"cu.chat_id = @chat_id",
 	}
 	args := pgx.NamedArgs{
 		"chat_id": filter.ChatID,
+		"now":     time.Now().UTC(),
 	}
 
 	if v := filter.ExcludedIDs; len(v) != 0 {
@@ -295,26 +709,37 @@ func (s *ChatService) getChatMembers(tx *sql.Tx, filter *GetMembersFilters) ([]*
 		where = append(where, "cu.user_id NOT IN "+idList)
 	}
 
+	nickname := "NULL"
+	nicknameJoin := ""
+	if v := filter.ViewerID; v != nil {
+		nickname = "fp.nickname"
+		nicknameJoin = "LEFT JOIN friend_profiles fp ON fp.owner_id = @viewer_id AND fp.friend_id = u.id "
+		args["viewer_id"] = *v
+	}
+
 	rows, err := tx.Query(`
 		SELECT 
-			u.id, u.username, u.email, u.active, u.password, u.created_at, u.updated_at 
+			u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color, `+nickname+`,
+			cs.text, cs.emoji, cs.expires_at, cs.do_not_disturb
 		FROM 
-			chat_to_user cu
-		JOIN users u on u.id = cu.user_id `+
+			chat_members cu
+		JOIN users u on u.id = cu.user_id
+		LEFT JOIN user_custom_statuses cs ON cs.user_id = u.id AND (cs.expires_at IS NULL OR cs.expires_at > @now) `+
+		nicknameJoin+
 		whereSQL(where)+";",
 		args,
 	)
 
-	members := make([]*models.User, 0)
+	members := make([]*models.PublicUser, 0)
 
 	if err != nil {
 		return members, nil
 	}
 
 	for rows.Next() {
-		member, err := scanUser(rows)
+		member, err := scanChatMember(rows)
 		if err != nil {
-			return make([]*models.User, 0), err
+			return make([]*models.PublicUser, 0), err
 		}
 		members = append(members, member)
 	}
@@ -325,6 +750,8 @@ func (s *ChatService) getChatMembers(tx *sql.Tx, filter *GetMembersFilters) ([]*
 type GetMembersFilters struct {
 	ExcludedIDs []int
 	ChatID      int
+	// ViewerID loads the nicknames this user gave the members
+	ViewerID *int
 }
 
 type GetChatsFilters struct {
@@ -0,0 +1,415 @@
+package store
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/types"
+	"github.com/kacperhemperek/discord-go/utils"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	DataExportNotFoundErr   = errors.New("data export not found")
+	DataExportInProgressErr = errors.New("data export is already being built")
+)
+
+type DataExportServiceInterface interface {
+	CreateExport(userID int) (*models.DataExport, error)
+	GetExport(exportID, userID int) (*models.DataExport, error)
+	OpenExport(export *models.DataExport) (*os.File, error)
+	FailInterruptedExports() error
+	DeleteExpiredExports() error
+	GetExportFileNames(userID int) ([]string, error)
+	RemoveExportFiles(fileNames []string)
+}
+
+// dataExportRetention is how long a built archive can be downloaded, after that it's deleted.
+const dataExportRetention = 7 * 24 * time.Hour
+
+// DataExportService builds ZIP archives with everything stored about a user in the directory set by EXPORTS_DIR,
+// unlike uploads the archives are not served publicly and can only be downloaded by their owner.
+type DataExportService struct {
+	db  *Database
+	dir string
+}
+
+// exportSection is a file of the archive holding a JSON array of the rows returned by query,
+// the query gets the user's id as its only argument.
+type exportSection struct {
+	name  string
+	query string
+	scan  func(scanner Scanner) (any, error)
+}
+
+type exportedNotification struct {
+	ID        int                    `json:"id"`
+	Type      types.NotificationType `json:"type"`
+	Seen      bool                   `json:"seen"`
+	Data      json.RawMessage        `json:"data"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+type exportedChatMembership struct {
+	Chat     *models.Chat   `json:"chat"`
+	Role     types.ChatRole `json:"role"`
+	JoinedAt time.Time      `json:"joinedAt"`
+}
+
+type exportedMessage struct {
+	ChatID int     `json:"chatId"`
+	Image  *string `json:"image"`
+	models.Message
+}
+
+var exportSections = []exportSection{
+	{
+		name:  "friendships.json",
+		query: "SELECT id, inviter_id, friend_id, status, seen, requested_at, status_updated_at FROM friendships WHERE (inviter_id = $1 OR friend_id = $1) AND status = 'accepted' ORDER BY id;",
+		scan:  scanAny(scanFriendship),
+	},
+	{
+		name:  "friend_requests.json",
+		query: "SELECT id, inviter_id, friend_id, status, seen, requested_at, status_updated_at FROM friendships WHERE (inviter_id = $1 OR friend_id = $1) AND status <> 'accepted' ORDER BY id;",
+		scan:  scanAny(scanFriendship),
+	},
+	{
+		name:  "notifications.json",
+		query: "SELECT id, type, COALESCE(seen, FALSE), data, created_at FROM notifications WHERE user_id = $1 ORDER BY id;",
+		scan: func(scanner Scanner) (any, error) {
+			n := &exportedNotification{}
+			var data []byte
+			if err := scanner.Scan(&n.ID, &n.Type, &n.Seen, &data, &n.CreatedAt); err != nil {
+				return nil, err
+			}
+			n.Data = data
+			return n, nil
+		},
+	},
+	{
+		name: "chats.json",
+		query: `
+			SELECT c.id, c.name, c.type, c.server_id, c.slow_mode_seconds, c.description, c.topic, c.icon_url, c.created_at, c.updated_at,
+			       cu.role, cu.created_at
+			FROM chat_to_user cu JOIN chats c ON c.id = cu.chat_id
+			WHERE cu.user_id = $1 ORDER BY cu.created_at;`,
+		scan: func(scanner Scanner) (any, error) {
+			m := &exportedChatMembership{Chat: &models.Chat{}}
+			err := scanner.Scan(
+				&m.Chat.ID,
+				&m.Chat.Name,
+				&m.Chat.Type,
+				&m.Chat.ServerID,
+				&m.Chat.SlowModeSeconds,
+				&m.Chat.Description,
+				&m.Chat.Topic,
+				&m.Chat.IconURL,
+				&m.Chat.CreatedAt,
+				&m.Chat.UpdatedAt,
+				&m.Role,
+				&m.JoinedAt,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return m, nil
+		},
+	},
+	{
+		name:  "messages.json",
+		query: "SELECT id, COALESCE(text, ''), image, kind, system_data, chat_id, created_at, updated_at FROM messages WHERE sender_id = $1 AND kind = 'user' ORDER BY id;",
+		scan: func(scanner Scanner) (any, error) {
+			m := &exportedMessage{}
+			err := scanner.Scan(
+				&m.ID,
+				&m.Text,
+				&m.Image,
+				&m.Kind,
+				&m.System,
+				&m.ChatID,
+				&m.CreatedAt,
+				&m.UpdatedAt,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return m, nil
+		},
+	},
+}
+
+// CreateExport stores a pending export and starts building its archive in the background.
+func (s *DataExportService) CreateExport(userID int) (*models.DataExport, error) {
+	defer utils.LogServiceCall("DataExportService", "CreateExport", time.Now())
+	row := s.db.QueryRow(
+		"INSERT INTO data_exports (user_id) VALUES ($1) RETURNING id, user_id, status, file_name, created_at, completed_at;",
+		userID,
+	)
+	export, err := scanDataExport(row)
+	if isExportInProgress(err) {
+		return nil, DataExportInProgressErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	go s.build(export.ID, export.UserID)
+	return export, nil
+}
+
+func (s *DataExportService) GetExport(exportID, userID int) (*models.DataExport, error) {
+	defer utils.LogServiceCall("DataExportService", "GetExport", time.Now())
+	row := s.db.QueryRow(
+		"SELECT id, user_id, status, file_name, created_at, completed_at FROM data_exports WHERE id = $1 AND user_id = $2;",
+		exportID,
+		userID,
+	)
+	export, err := scanDataExport(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, DataExportNotFoundErr
+	}
+	return export, err
+}
+
+// OpenExport opens the archive of a ready export, the caller has to close it.
+func (s *DataExportService) OpenExport(export *models.DataExport) (*os.File, error) {
+	defer utils.LogServiceCall("DataExportService", "OpenExport", time.Now())
+	if export.FileName == nil {
+		return nil, DataExportNotFoundErr
+	}
+	file, err := os.Open(filepath.Join(s.dir, filepath.Base(*export.FileName)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, DataExportNotFoundErr
+	}
+	return file, err
+}
+
+// FailInterruptedExports marks exports that were still being built when the server stopped as failed,
+// otherwise their users could never request a new one.
+func (s *DataExportService) FailInterruptedExports() error {
+	defer utils.LogServiceCall("DataExportService", "FailInterruptedExports", time.Now())
+	pending := types.PendingDataExport
+	failed := types.FailedDataExport
+	_, err := s.db.Exec(
+		"UPDATE data_exports SET status = $1, completed_at = $2 WHERE status = $3;",
+		failed.String(),
+		time.Now().UTC(),
+		pending.String(),
+	)
+	return err
+}
+
+// DeleteExpiredExports deletes exports completed longer than the retention period ago together with their archives.
+func (s *DataExportService) DeleteExpiredExports() error {
+	defer utils.LogServiceCall("DataExportService", "DeleteExpiredExports", time.Now())
+	pending := types.PendingDataExport
+	rows, err := s.db.Query(
+		"DELETE FROM data_exports WHERE status <> $1 AND completed_at < $2 RETURNING file_name;",
+		pending.String(),
+		time.Now().UTC().Add(-dataExportRetention),
+	)
+	if err != nil {
+		return err
+	}
+	fileNames, err := scanFileNames(rows)
+	if err != nil {
+		return err
+	}
+	s.RemoveExportFiles(fileNames)
+	return nil
+}
+
+// GetExportFileNames returns the archives of the user's exports, they have to be read before the user is deleted
+// because the exports are deleted along with the user but the files are not.
+func (s *DataExportService) GetExportFileNames(userID int) ([]string, error) {
+	defer utils.LogServiceCall("DataExportService", "GetExportFileNames", time.Now())
+	rows, err := s.db.Query("SELECT file_name FROM data_exports WHERE user_id = $1;", userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanFileNames(rows)
+}
+
+// RemoveExportFiles removes the archives from disk, failures are only logged.
+func (s *DataExportService) RemoveExportFiles(fileNames []string) {
+	for _, fileName := range fileNames {
+		s.removeFile(fileName)
+	}
+}
+
+func (s *DataExportService) removeFile(fileName string) {
+	err := os.Remove(filepath.Join(s.dir, filepath.Base(fileName)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("could not remove data export file", "fileName", fileName, "error", err)
+	}
+}
+
+// build writes the archive and records whether it succeeded, it runs after the request that created the export is done.
+func (s *DataExportService) build(exportID, userID int) {
+	defer utils.LogServiceCall("DataExportService", "build", time.Now())
+	fileName := uuid.New().String() + ".zip"
+	status := types.ReadyDataExport
+	storedName := &fileName
+	if err := s.writeArchive(userID, fileName); err != nil {
+		slog.Error("could not build data export", "exportID", exportID, "userID", userID, "error", err)
+		status = types.FailedDataExport
+		storedName = nil
+	}
+	res, err := s.db.Exec(
+		"UPDATE data_exports SET status = $1, file_name = $2, completed_at = $3 WHERE id = $4;",
+		status.String(),
+		storedName,
+		time.Now().UTC(),
+		exportID,
+	)
+	var updated int64
+	if err == nil {
+		updated, err = res.RowsAffected()
+	}
+	if err != nil {
+		slog.Error("could not update data export", "exportID", exportID, "error", err)
+	}
+	// nobody can download the archive when its export wasn't updated or was deleted with its user meanwhile
+	if storedName != nil && (err != nil || updated == 0) {
+		s.removeFile(fileName)
+	}
+}
+
+// writeArchive writes the archive under a temporary name and moves it in place once it's complete,
+// so a half written file is never handed out.
+func (s *DataExportService) writeArchive(userID int, fileName string) error {
+	path := filepath.Join(s.dir, fileName)
+	partPath := path + ".part"
+	file, err := os.Create(partPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(partPath)
+	if err := s.writeArchiveTo(userID, file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partPath, path)
+}
+
+// writeArchiveTo reads every section in a single repeatable read transaction, so the files of the archive
+// are consistent with each other even when the user keeps chatting while it's built.
+func (s *DataExportService) writeArchiveTo(userID int, w io.Writer) error {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+	archive := zip.NewWriter(w)
+	user, err := scanUser(tx.QueryRow(
+		"SELECT id, username, handle, display_name, bio, avatar, banner_color, email, active, password, created_at, updated_at FROM users WHERE id = $1;",
+		userID,
+	))
+	if err != nil {
+		return err
+	}
+	profile, err := archive.Create("profile.json")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(profile).Encode(user); err != nil {
+		return err
+	}
+	for _, section := range exportSections {
+		if err := writeExportSection(tx, archive, userID, &section); err != nil {
+			return fmt.Errorf("%s: %w", section.name, err)
+		}
+	}
+	return archive.Close()
+}
+
+// writeExportSection encodes rows one at a time as they are read from the database, so exports of
+// very active users never have to fit in memory.
+func writeExportSection(tx *sql.Tx, archive *zip.Writer, userID int, section *exportSection) error {
+	rows, err := tx.Query(section.query, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	file, err := archive.Create(section.name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(file, "["); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	first := true
+	for rows.Next() {
+		item, err := section.scan(rows)
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := io.WriteString(file, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(file, "]\n")
+	return err
+}
+
+// scanFileNames reads the file_name column, exports without an archive are skipped.
+func scanFileNames(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+	fileNames := make([]string, 0)
+	for rows.Next() {
+		var fileName sql.NullString
+		if err := rows.Scan(&fileName); err != nil {
+			return nil, err
+		}
+		if fileName.Valid {
+			fileNames = append(fileNames, fileName.String)
+		}
+	}
+	return fileNames, rows.Err()
+}
+
+// scanAny adapts a typed scanner to the one used by export sections.
+func scanAny[T any](scan func(Scanner) (T, error)) func(Scanner) (any, error) {
+	return func(scanner Scanner) (any, error) {
+		return scan(scanner)
+	}
+}
+
+func isExportInProgress(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "data_exports_pending_index"
+}
+
+func NewDataExportService(db *Database) *DataExportService {
+	dir := os.Getenv("EXPORTS_DIR")
+	if dir == "" {
+		dir = "exports"
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		fmt.Println("Error creating exports directory")
+		panic(err)
+	}
+	return &DataExportService{db: db, dir: dir}
+}
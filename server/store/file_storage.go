@@ -0,0 +1,111 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kacperhemperek/discord-go/utils"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const uploadsURLPrefix = "/uploads/"
+
+var (
+	UnsupportedFileTypeErr = errors.New("unsupported file type")
+)
+
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// FileStorageInterface stores user uploaded content and hands out urls it can be fetched from.
+type FileStorageInterface interface {
+	SaveImage(folder string, file io.Reader) (string, error)
+	Delete(fileURL string) error
+	Handler() http.Handler
+}
+
+// FileStorage keeps uploaded files on the local disk in the directory set by UPLOADS_DIR
+// and serves them under /uploads/.
+type FileStorage struct {
+	dir string
+}
+
+// SaveImage stores the image under a random name in the folder and returns its url,
+// the file type is detected from the content instead of trusting the client.
+func (s *FileStorage) SaveImage(folder string, file io.Reader) (string, error) {
+	defer utils.LogServiceCall("FileStorage", "SaveImage", time.Now())
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	head = head[:n]
+	ext, found := imageExtensions[http.DetectContentType(head)]
+	if !found {
+		return "", UnsupportedFileTypeErr
+	}
+	if err := os.MkdirAll(filepath.Join(s.dir, folder), 0o755); err != nil {
+		return "", err
+	}
+	name := path.Join(folder, uuid.New().String()+ext)
+	f, err := os.Create(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(head); err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, file); err != nil {
+		return "", err
+	}
+	return uploadsURLPrefix + name, nil
+}
+
+// Delete removes a file previously returned by SaveImage, urls pointing elsewhere are ignored.
+func (s *FileStorage) Delete(fileURL string) error {
+	defer utils.LogServiceCall("FileStorage", "Delete", time.Now())
+	name, found := strings.CutPrefix(fileURL, uploadsURLPrefix)
+	if !found {
+		return nil
+	}
+	name = path.Clean("/" + name)
+	err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileStorage) Handler() http.Handler {
+	fileServer := http.StripPrefix(uploadsURLPrefix, http.FileServer(http.Dir(s.dir)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+func NewFileStorage() *FileStorage {
+	dir := os.Getenv("UPLOADS_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Println("Error creating uploads directory")
+		panic(err)
+	}
+	return &FileStorage{dir: dir}
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/types"
+	"github.com/kacperhemperek/discord-go/utils"
+	"os"
+	"time"
+)
+
+const defaultRejectedFriendRequestCooldown = 7 * 24 * time.Hour
+
+var (
+	FriendRequestBlockedErr           = errors.New("users have blocked each other")
+	FriendRequestsDisabledErr         = errors.New("user is not accepting friend requests")
+	FriendRequestsFriendsOfFriendsErr = errors.New("user only accepts friend requests from friends of friends")
+)
+
+// FriendRequestCooldownError is returned when the sender's previous request was rejected too recently.
+type FriendRequestCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *FriendRequestCooldownError) Error() string {
+	return fmt.Sprintf("friend request was rejected recently, retry after %s", e.RetryAfter)
+}
+
+type FriendRequestPolicyInterface interface {
+	CheckFriendRequest(senderID, recipientID int, existing *models.Friendship) error
+}
+
+// FriendRequestPolicy decides whether a friend request can be sent. It checks blocks, the recipient's
+// privacy setting and the cooldown after a rejected request, which is set with FRIEND_REQUEST_COOLDOWN.
+type FriendRequestPolicy struct {
+	db                      *Database
+	blocks                  BlockServiceInterface
+	users                   UserServiceInterface
+	rejectedRequestCooldown time.Duration
+	now                     func() time.Time
+}
+
+// CheckFriendRequest returns nil when the sender can send the recipient a friend request. The existing
+// friendship between them is passed in when there is one so it isn't loaded twice.
+func (p *FriendRequestPolicy) CheckFriendRequest(senderID, recipientID int, existing *models.Friendship) error {
+	defer utils.LogServiceCall("FriendRequestPolicy", "CheckFriendRequest", time.Now())
+	blocked, err := p.blocks.IsBlockedEitherWay(senderID, recipientID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return FriendRequestBlockedErr
+	}
+	settings, err := p.users.GetPrivacySettings(recipientID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case settings.FriendRequests.Is(types.NobodyFriendRequestPrivacy):
+		return FriendRequestsDisabledErr
+	case settings.FriendRequests.Is(types.FriendsOfFriendsFriendRequestPrivacy):
+		mutual, err := p.haveMutualFriend(senderID, recipientID)
+		if err != nil {
+			return err
+		}
+		if !mutual {
+			return FriendRequestsFriendsOfFriendsErr
+		}
+	}
+	return p.checkCooldown(senderID, existing)
+}
+
+// checkCooldown only limits the user whose request was rejected, the user who rejected it can send one right away.
+func (p *FriendRequestPolicy) checkCooldown(senderID int, existing *models.Friendship) error {
+	if existing == nil || existing.Status != "rejected" || existing.InviterID != senderID {
+		return nil
+	}
+	if !existing.StatusChangedAt.Valid {
+		return errors.New("status changed at is null from database this should not happen if status is changed properly")
+	}
+	retryAfter := existing.StatusChangedAt.Time.Add(p.rejectedRequestCooldown).Sub(p.now())
+	if retryAfter > 0 {
+		return &FriendRequestCooldownError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (p *FriendRequestPolicy) haveMutualFriend(userID, otherUserID int) (bool, error) {
+	var mutual bool
+	err := p.db.QueryRow(
+		fmt.Sprintf(
+			"SELECT EXISTS(SELECT 1 FROM (%s) uf JOIN (%s) ofr ON ofr.id = uf.id);",
+			fmt.Sprintf(acceptedFriendIDsSQL, "$1"),
+			fmt.Sprintf(acceptedFriendIDsSQL, "$2"),
+		),
+		userID,
+		otherUserID,
+	).Scan(&mutual)
+	return mutual, err
+}
+
+func NewFriendRequestPolicy(db *Database, blocks BlockServiceInterface, users UserServiceInterface) *FriendRequestPolicy {
+	cooldown := defaultRejectedFriendRequestCooldown
+	if v := os.Getenv("FRIEND_REQUEST_COOLDOWN"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err == nil && parsed < 0 {
+			err = errors.New("cooldown can't be negative")
+		}
+		if err != nil {
+			fmt.Println("Invalid FRIEND_REQUEST_COOLDOWN, expected a duration like 168h")
+			panic(err)
+		}
+		cooldown = parsed
+	}
+	return &FriendRequestPolicy{
+		db:                      db,
+		blocks:                  blocks,
+		users:                   users,
+		rejectedRequestCooldown: cooldown,
+		now:                     time.Now,
+	}
+}
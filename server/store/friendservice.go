@@ -17,42 +17,42 @@ type FriendshipService struct {
 }
 
 type FriendshipServiceInterface interface {
-	SendFriendRequest(inviterID, friendID int) error
+	SendFriendRequest(inviterID, friendID int) (int, error)
 	GetUsersFriendRequests(userID int) ([]*models.FriendRequest, error)
+	GetUsersOutgoingFriendRequests(userID int) ([]*models.FriendRequest, error)
 	GetFriendshipByUsers(userOneID, userTwoID int) (*models.Friendship, error)
 	GetFriendshipByID(requestID int) (*models.Friendship, error)
 	AcceptFriendRequest(requestID int) error
 	RejectFriendRequest(requestID int) error
 	MakeFriendshipPending(requestID int) error
-	DeleteRequestAndSendNew(requestID, inviterID, friendID int) error
-	GetFriendsByUserID(userID int) ([]*models.User, error)
+	DeleteRequestAndSendNew(requestID, inviterID, friendID int) (int, error)
+	GetFriendsByUserID(userID int) ([]*models.PublicUser, error)
+	GetMutualFriends(userID, otherUserID int) ([]*models.PublicUser, error)
+	GetFriendSuggestions(userID, limit int) ([]*models.FriendSuggestion, error)
+	GetFriendProfile(ownerID, friendID int) (*models.FriendProfile, error)
+	SaveFriendProfile(ownerID int, profile *models.FriendProfile) (*models.FriendProfile, error)
 	DeleteFriendship(friendshipID int) error
 }
 
-func (s *FriendshipService) SendFriendRequest(inviterID, friendID int) error {
+// SendFriendRequest creates a pending friendship and returns its id.
+func (s *FriendshipService) SendFriendRequest(inviterID, friendID int) (int, error) {
 	defer utils.LogServiceCall("FriendshipService", "SendFriendRequest", time.Now())
 	ctx := context.Background()
-	context.WithTimeout(ctx, 200*time.Millisecond)
-	_, err := s.db.QueryContext(
+	row := s.db.QueryRowContext(
 		ctx,
-		"INSERT INTO friendships (inviter_id, friend_id) VALUES ($1, $2)",
+		"INSERT INTO friendships (inviter_id, friend_id) VALUES ($1, $2) RETURNING id",
 		inviterID, friendID)
 
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return scanID(row)
 }
 
 func (s *FriendshipService) GetUsersFriendRequests(userID int) ([]*models.FriendRequest, error) {
 	defer utils.LogServiceCall("FriendshipService", "GetUsersFriendRequests", time.Now())
 	ctx := context.Background()
-	context.WithTimeout(ctx, 200*time.Millisecond)
 	rows, err := s.db.QueryContext(
 		ctx,
 		`
-		SELECT f.id, f.status, f.requested_at, f.status_updated_at, u.id, u.username, u.email, u.active, u.password, u.created_at, u.updated_at 
+		SELECT f.id, f.status, f.requested_at, f.status_updated_at, u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color 
 		FROM friendships f JOIN users u ON f.inviter_id = u.id WHERE f.friend_id = $1 AND f.status = 'pending';
 		`,
 		userID,
@@ -85,10 +85,37 @@ func (s *FriendshipService) GetUsersFriendRequests(userID int) ([]*models.Friend
 	return users, nil
 }
 
+// GetUsersOutgoingFriendRequests returns pending requests sent by the user, the user of each request is its recipient.
+func (s *FriendshipService) GetUsersOutgoingFriendRequests(userID int) ([]*models.FriendRequest, error) {
+	defer utils.LogServiceCall("FriendshipService", "GetUsersOutgoingFriendRequests", time.Now())
+	ctx := context.Background()
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+		SELECT f.id, f.status, f.requested_at, f.status_updated_at, u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color 
+		FROM friendships f JOIN users u ON f.friend_id = u.id WHERE f.inviter_id = $1 AND f.status = 'pending'
+		ORDER BY f.requested_at DESC;
+		`,
+		userID,
+	)
+	requests := make([]*models.FriendRequest, 0)
+	if err != nil {
+		return requests, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		request, err := scanFriendRequest(rows)
+		if err != nil {
+			return make([]*models.FriendRequest, 0), err
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
+
 func (s *FriendshipService) GetFriendshipByUsers(userOneID, userTwoID int) (*models.Friendship, error) {
 	defer utils.LogServiceCall("FriendshipService", "GetFriendshipByUsers", time.Now())
 	ctx := context.Background()
-	context.WithTimeout(ctx, 200*time.Millisecond)
 	row := s.db.QueryRowContext(
 		ctx,
 		"SELECT id, inviter_id, friend_id, status, seen, requested_at, status_updated_at FROM friendships WHERE (inviter_id = $1 AND friend_id = $2) OR (inviter_id = $2 AND friend_id = $1);",
@@ -107,7 +134,6 @@ func (s *FriendshipService) GetFriendshipByUsers(userOneID, userTwoID int) (*mod
 func (s *FriendshipService) GetFriendshipByID(requestID int) (*models.Friendship, error) {
 	defer utils.LogServiceCall("FriendshipService", "GetFriendshipByID", time.Now())
 	ctx := context.Background()
-	context.WithTimeout(ctx, 200*time.Millisecond)
 	row := s.db.QueryRowContext(
 		ctx,
 		"SELECT id, inviter_id, friend_id, status, seen, requested_at, status_updated_at FROM friendships WHERE id = $1;",
@@ -126,7 +152,6 @@ func (s *FriendshipService) GetFriendshipByID(requestID int) (*models.Friendship
 func (s *FriendshipService) AcceptFriendRequest(requestID int) error {
 	defer utils.LogServiceCall("FriendshipService", "AcceptFriendRequest", time.Now())
 	ctx := context.Background()
-	context.WithTimeout(ctx, 200*time.Millisecond)
 	_, err := s.db.ExecContext(
 		ctx,
 		"UPDATE friendships SET status = 'accepted', status_updated_at = CURRENT_TIMESTAMP WHERE id = $1;",
@@ -143,7 +168,6 @@ func (s *FriendshipService) AcceptFriendRequest(requestID int) error {
 func (s *FriendshipService) RejectFriendRequest(requestID int) error {
 	defer utils.LogServiceCall("FriendshipService", "RejectFriendRequest", time.Now())
 	ctx := context.Background()
-	context.WithTimeout(ctx, 200*time.Millisecond)
 	_, err := s.db.ExecContext(
 		ctx,
 		"UPDATE friendships SET status = 'rejected', status_updated_at = CURRENT_TIMESTAMP WHERE id = $1;",
@@ -160,7 +184,6 @@ func (s *FriendshipService) RejectFriendRequest(requestID int) error {
 func (s *FriendshipService) MakeFriendshipPending(requestID int) error {
 	defer utils.LogServiceCall("FriendshipService", "MakeFriendshipPending", time.Now())
 	ctx := context.Background()
-	context.WithTimeout(ctx, 200*time.Millisecond)
 
 	_, err := s.db.ExecContext(
 		ctx,
@@ -175,12 +198,12 @@ func (s *FriendshipService) MakeFriendshipPending(requestID int) error {
 	return nil
 }
 
-func (s *FriendshipService) DeleteRequestAndSendNew(requestID, inviterID, friendID int) error {
+func (s *FriendshipService) DeleteRequestAndSendNew(requestID, inviterID, friendID int) (int, error) {
 	defer utils.LogServiceCall("FriendshipService", "DeleteRequestAndSendNew", time.Now())
 	tx, err := s.db.Begin()
 
 	if err != nil {
-		return err
+		return 0, err
 	}
 	_, err = tx.Exec(
 		"DELETE FROM friendships WHERE id = $1;",
@@ -189,28 +212,29 @@ func (s *FriendshipService) DeleteRequestAndSendNew(requestID, inviterID, friend
 
 	if err != nil {
 		rollback(tx)
-		return err
+		return 0, err
 	}
 
-	_, err = tx.Exec(
-		"INSERT INTO friendships (inviter_id, friend_id) VALUES ($1, $2);",
+	row := tx.QueryRow(
+		"INSERT INTO friendships (inviter_id, friend_id) VALUES ($1, $2) RETURNING id;",
 		inviterID, friendID,
 	)
+	newRequestID, err := scanID(row)
 
 	if err != nil {
 		rollback(tx)
-		return err
+		return 0, err
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return newRequestID, nil
 }
 
-func (s *FriendshipService) GetFriendsByUserID(userID int) ([]*models.User, error) {
+func (s *FriendshipService) GetFriendsByUserID(userID int) ([]*models.PublicUser, error) {
 	defer utils.LogServiceCall("FriendshipService", "GetFriendsByUserID", time.Now())
 
 	errChan := make(chan error)
@@ -227,8 +251,9 @@ func (s *FriendshipService) GetFriendsByUserID(userID int) ([]*models.User, erro
 	go func() {
 		defer wg.Done()
 		acceptedFriends, err := s.db.Query(
-			"SELECT u.id, u.username, u.email, u.active, u.password, u.created_at, u.updated_at, f.status_updated_at FROM friendships f JOIN public.users u on u.id = f.inviter_id WHERE friend_id=$1 AND f.status='accepted'",
+			"SELECT u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color, f.status_updated_at, fp.nickname, cs.text, cs.emoji, cs.expires_at, cs.do_not_disturb FROM friendships f JOIN public.users u on u.id = f.inviter_id LEFT JOIN friend_profiles fp ON fp.owner_id = $1 AND fp.friend_id = u.id LEFT JOIN user_custom_statuses cs ON cs.user_id = u.id AND (cs.expires_at IS NULL OR cs.expires_at > $2) WHERE f.friend_id=$1 AND f.status='accepted'",
 			userID,
+			time.Now().UTC(),
 		)
 
 		if err != nil {
@@ -249,8 +274,9 @@ func (s *FriendshipService) GetFriendsByUserID(userID int) ([]*models.User, erro
 	go func() {
 		defer wg.Done()
 		invitedFriends, err := s.db.Query(
-			"SELECT u.id, u.username, u.email, u.active, u.password, u.created_at, u.updated_at, f.status_updated_at FROM friendships f JOIN public.users u on u.id = f.friend_id WHERE inviter_id=$1 AND f.status='accepted';",
+			"SELECT u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color, f.status_updated_at, fp.nickname, cs.text, cs.emoji, cs.expires_at, cs.do_not_disturb FROM friendships f JOIN public.users u on u.id = f.friend_id LEFT JOIN friend_profiles fp ON fp.owner_id = $1 AND fp.friend_id = u.id LEFT JOIN user_custom_statuses cs ON cs.user_id = u.id AND (cs.expires_at IS NULL OR cs.expires_at > $2) WHERE f.inviter_id=$1 AND f.status='accepted';",
 			userID,
+			time.Now().UTC(),
 		)
 		if err != nil {
 			errChan <- err
@@ -267,7 +293,7 @@ func (s *FriendshipService) GetFriendsByUserID(userID int) ([]*models.User, erro
 	}()
 
 	friends := make([]*models.Friend, 0)
-	users := make([]*models.User, 0)
+	users := make([]*models.PublicUser, 0)
 
 	for {
 		select {
@@ -280,7 +306,7 @@ func (s *FriendshipService) GetFriendsByUserID(userID int) ([]*models.User, erro
 				return friends[i].AcceptedAt.Before(friends[j].AcceptedAt)
 			})
 			for _, friend := range friends {
-				user := &friend.User
+				user := &friend.PublicUser
 
 				users = append(users, user)
 			}
@@ -314,17 +340,20 @@ func (s *FriendshipService) DeleteFriendship(friendshipID int) error {
 		friendship.FriendID,
 	)
 	chatID, err := scanID(row)
-	if err != nil {
+	// pending and rejected requests have no private chat yet
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		rollback(tx)
 		return err
 	}
-	_, err = tx.Exec(
-		"DELETE FROM chats WHERE id = $1",
-		chatID,
-	)
-	if err != nil {
-		rollback(tx)
-		return err
+	if err == nil {
+		_, err = tx.Exec(
+			"DELETE FROM chats WHERE id = $1",
+			chatID,
+		)
+		if err != nil {
+			rollback(tx)
+			return err
+		}
 	}
 	_, err = tx.Exec(
 		"DELETE FROM friendships WHERE id = $1",
@@ -341,6 +370,149 @@ func (s *FriendshipService) DeleteFriendship(friendshipID int) error {
 	}
 	return nil
 }
+
+// acceptedFriendIDsSQL selects ids of the user's friends, the parameter is the user's id. Like GetFriendsByUserID
+// it looks at both sides of the friendship separately so each half can use its index.
+const acceptedFriendIDsSQL = `
+	SELECT friend_id AS id FROM friendships WHERE inviter_id = %[1]s AND status = 'accepted'
+	UNION ALL
+	SELECT inviter_id AS id FROM friendships WHERE friend_id = %[1]s AND status = 'accepted'`
+
+func (s *FriendshipService) GetMutualFriends(userID, otherUserID int) ([]*models.PublicUser, error) {
+	defer utils.LogServiceCall("FriendshipService", "GetMutualFriends", time.Now())
+	rows, err := s.db.Query(
+		fmt.Sprintf(`
+		WITH user_friends AS (%s),
+		     other_friends AS (%s)
+		SELECT u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color
+		FROM user_friends uf
+		JOIN other_friends ofr ON ofr.id = uf.id
+		JOIN users u ON u.id = uf.id
+		ORDER BY u.username, u.id;`,
+			fmt.Sprintf(acceptedFriendIDsSQL, "$1"),
+			fmt.Sprintf(acceptedFriendIDsSQL, "$2"),
+		),
+		userID,
+		otherUserID,
+	)
+	users := make([]*models.PublicUser, 0)
+	if err != nil {
+		return users, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		user, err := scanPublicUser(rows)
+		if err != nil {
+			return make([]*models.PublicUser, 0), err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// GetFriendSuggestions ranks friends of the user's friends by the number of mutual friends and then shared
+// group chats. Users the user already has any friendship with, including rejected ones, and blocked users are left out.
+func (s *FriendshipService) GetFriendSuggestions(userID, limit int) ([]*models.FriendSuggestion, error) {
+	defer utils.LogServiceCall("FriendshipService", "GetFriendSuggestions", time.Now())
+	rows, err := s.db.Query(
+		fmt.Sprintf(`
+		WITH user_friends AS (%s),
+		     friends_of_friends AS (
+		         SELECT f.friend_id AS id FROM user_friends uf
+		             JOIN friendships f ON f.inviter_id = uf.id AND f.status = 'accepted'
+		         UNION ALL
+		         SELECT f.inviter_id AS id FROM user_friends uf
+		             JOIN friendships f ON f.friend_id = uf.id AND f.status = 'accepted'
+		     ),
+		     candidates AS (
+		         SELECT id, COUNT(*) AS mutual_friends FROM friends_of_friends
+		         WHERE id <> $1
+		         GROUP BY id
+		     ),
+		     shared_chats AS (
+		         SELECT other.user_id AS id, COUNT(*) AS shared_chats
+		         FROM chat_to_user me
+		         JOIN chats c ON c.id = me.chat_id AND c.type = 'group'
+		         JOIN chat_to_user other ON other.chat_id = me.chat_id AND other.user_id <> $1
+		         WHERE me.user_id = $1
+		         GROUP BY other.user_id
+		     )
+		SELECT u.id, u.username, u.handle, c.mutual_friends, COALESCE(sc.shared_chats, 0)
+		FROM candidates c
+		JOIN users u ON u.id = c.id
+		LEFT JOIN shared_chats sc ON sc.id = c.id
+		WHERE NOT EXISTS (
+		    SELECT 1 FROM friendships f
+		    WHERE (f.inviter_id = $1 AND f.friend_id = c.id) OR (f.inviter_id = c.id AND f.friend_id = $1)
+		)
+		AND NOT EXISTS (
+		    SELECT 1 FROM user_blocks b
+		    WHERE (b.blocker_id = $1 AND b.blocked_id = c.id) OR (b.blocker_id = c.id AND b.blocked_id = $1)
+		)
+		ORDER BY c.mutual_friends DESC, COALESCE(sc.shared_chats, 0) DESC, u.id
+		LIMIT $2;`,
+			fmt.Sprintf(acceptedFriendIDsSQL, "$1"),
+		),
+		userID,
+		limit,
+	)
+	suggestions := make([]*models.FriendSuggestion, 0)
+	if err != nil {
+		return suggestions, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		suggestion, err := scanFriendSuggestion(rows)
+		if err != nil {
+			return make([]*models.FriendSuggestion, 0), err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
+
+// GetFriendProfile returns the owner's nickname and note for the friend, an empty profile is returned when none was saved.
+func (s *FriendshipService) GetFriendProfile(ownerID, friendID int) (*models.FriendProfile, error) {
+	defer utils.LogServiceCall("FriendshipService", "GetFriendProfile", time.Now())
+	row := s.db.QueryRow(
+		"SELECT friend_id, nickname, note, updated_at FROM friend_profiles WHERE owner_id = $1 AND friend_id = $2;",
+		ownerID,
+		friendID,
+	)
+	profile, err := scanFriendProfile(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.FriendProfile{FriendID: friendID}, nil
+	}
+	return profile, err
+}
+
+// SaveFriendProfile replaces the owner's nickname and note for the friend, the row is removed once both are cleared.
+func (s *FriendshipService) SaveFriendProfile(ownerID int, profile *models.FriendProfile) (*models.FriendProfile, error) {
+	defer utils.LogServiceCall("FriendshipService", "SaveFriendProfile", time.Now())
+	if profile.Nickname == nil && profile.Note == nil {
+		_, err := s.db.Exec(
+			"DELETE FROM friend_profiles WHERE owner_id = $1 AND friend_id = $2;",
+			ownerID,
+			profile.FriendID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &models.FriendProfile{FriendID: profile.FriendID}, nil
+	}
+	row := s.db.QueryRow(`
+		INSERT INTO friend_profiles (owner_id, friend_id, nickname, note) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (owner_id, friend_id) DO UPDATE
+			SET nickname = EXCLUDED.nickname, note = EXCLUDED.note, updated_at = CURRENT_TIMESTAMP
+		RETURNING friend_id, nickname, note, updated_at;`,
+		ownerID,
+		profile.FriendID,
+		profile.Nickname,
+		profile.Note,
+	)
+	return scanFriendProfile(row)
+}
+
 func NewFriendshipService(db *Database) *FriendshipService {
 	return &FriendshipService{db: db}
 }
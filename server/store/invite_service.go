@@ -0,0 +1,272 @@
+package store
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/types"
+	"github.com/kacperhemperek/discord-go/utils"
+	"math/big"
+	"time"
+)
+
+var (
+	InviteExpiredErr     = errors.New("invite has expired")
+	InviteRevokedErr     = errors.New("invite has been revoked")
+	InviteUsedUpErr      = errors.New("invite has reached its maximum number of uses")
+	AlreadyChatMemberErr = errors.New("user is already a chat member")
+)
+
+const (
+	inviteCodeAlphabet     = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	inviteCodeLength       = 8
+	inviteCodeInsertTrials = 5
+)
+
+type InviteServiceInterface interface {
+	CreateInvite(chatID, creatorID int, maxUses *int, expiresAt *time.Time) (*models.ChatInvite, error)
+	GetInviteByCode(code string) (*models.ChatInvite, error)
+	GetInvitePreview(code string) (*models.ChatInvitePreview, error)
+	GetChatInvites(chatID int) ([]*models.ChatInvite, error)
+	GetInviteUses(inviteID int) ([]*models.ChatInviteUse, error)
+	RevokeInvite(inviteID int, audit *ChatAuditLogInput) error
+	AcceptInvite(code string, userID int) (*models.ChatInvite, error)
+}
+
+type InviteService struct {
+	db *Database
+}
+
+func (s *InviteService) CreateInvite(chatID, creatorID int, maxUses *int, expiresAt *time.Time) (*models.ChatInvite, error) {
+	defer utils.LogServiceCall("InviteService", "CreateInvite", time.Now())
+	var lastErr error
+	// codes are short, so in the unlikely case of a collision we simply draw another one
+	for i := 0; i < inviteCodeInsertTrials; i++ {
+		code, err := newInviteCode()
+		if err != nil {
+			return nil, err
+		}
+		row := s.db.QueryRow(`
+			INSERT INTO chat_invites (code, chat_id, creator_id, max_uses, expires_at)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (code) DO NOTHING
+				RETURNING id, code, chat_id, creator_id, max_uses, uses, expires_at, revoked_at, created_at, updated_at;`,
+			code,
+			chatID,
+			creatorID,
+			maxUses,
+			expiresAt,
+		)
+		invite, err := scanChatInvite(row)
+		if err == nil {
+			return invite, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (s *InviteService) GetInviteByCode(code string) (*models.ChatInvite, error) {
+	defer utils.LogServiceCall("InviteService", "GetInviteByCode", time.Now())
+	row := s.db.QueryRow(`
+		SELECT id, code, chat_id, creator_id, max_uses, uses, expires_at, revoked_at, created_at, updated_at
+			FROM chat_invites WHERE code = $1;`,
+		code,
+	)
+	return scanChatInvite(row)
+}
+
+func (s *InviteService) GetInvitePreview(code string) (*models.ChatInvitePreview, error) {
+	defer utils.LogServiceCall("InviteService", "GetInvitePreview", time.Now())
+	invite, err := s.GetInviteByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInviteUsable(invite, time.Now()); err != nil {
+		return nil, err
+	}
+	row := s.db.QueryRow(`
+		SELECT c.name, (SELECT count(*) FROM chat_to_user cu WHERE cu.chat_id = c.id)
+			FROM chats c WHERE c.id = $1;`,
+		invite.ChatID,
+	)
+	preview := &models.ChatInvitePreview{
+		Code:      invite.Code,
+		ChatID:    invite.ChatID,
+		ExpiresAt: invite.ExpiresAt,
+	}
+	if err := row.Scan(&preview.ChatName, &preview.MemberCount); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+func (s *InviteService) GetChatInvites(chatID int) ([]*models.ChatInvite, error) {
+	defer utils.LogServiceCall("InviteService", "GetChatInvites", time.Now())
+	rows, err := s.db.Query(`
+		SELECT id, code, chat_id, creator_id, max_uses, uses, expires_at, revoked_at, created_at, updated_at
+			FROM chat_invites WHERE chat_id = $1 ORDER BY created_at DESC;`,
+		chatID,
+	)
+	invites := make([]*models.ChatInvite, 0)
+	if err != nil {
+		return invites, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		invite, err := scanChatInvite(rows)
+		if err != nil {
+			return make([]*models.ChatInvite, 0), err
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+func (s *InviteService) GetInviteUses(inviteID int) ([]*models.ChatInviteUse, error) {
+	defer utils.LogServiceCall("InviteService", "GetInviteUses", time.Now())
+	rows, err := s.db.Query(`
+		SELECT iu.id, iu.invite_id, iu.created_at,
+		       u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color
+			FROM chat_invite_uses iu JOIN users u ON u.id = iu.user_id
+			WHERE iu.invite_id = $1 ORDER BY iu.created_at DESC;`,
+		inviteID,
+	)
+	uses := make([]*models.ChatInviteUse, 0)
+	if err != nil {
+		return uses, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		use, err := scanChatInviteUse(rows)
+		if err != nil {
+			return make([]*models.ChatInviteUse, 0), err
+		}
+		uses = append(uses, use)
+	}
+	return uses, rows.Err()
+}
+
+func (s *InviteService) RevokeInvite(inviteID int, audit *ChatAuditLogInput) error {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("InviteService", "RevokeInvite", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE chat_invites SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL;",
+		inviteID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := logChatAction(tx, audit); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AcceptInvite adds the user to the invite's chat and records the use. The invite row is locked
+// for the duration of the transaction so concurrent acceptances cannot go over max uses.
+func (s *InviteService) AcceptInvite(code string, userID int) (*models.ChatInvite, error) {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("InviteService", "AcceptInvite", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRow(`
+		SELECT id, code, chat_id, creator_id, max_uses, uses, expires_at, revoked_at, created_at, updated_at
+			FROM chat_invites WHERE code = $1 FOR UPDATE;`,
+		code,
+	)
+	invite, err := scanChatInvite(row)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInviteUsable(invite, time.Now()); err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(
+		"INSERT INTO chat_to_user (chat_id, user_id) VALUES ($1, $2) ON CONFLICT (chat_id, user_id) DO NOTHING;",
+		invite.ChatID,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if added, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if added == 0 {
+		return nil, AlreadyChatMemberErr
+	}
+	err = createSystemMessage(tx, invite.ChatID, userID, &models.SystemMessageData{
+		Type:    types.MemberAddedSystemMessage,
+		UserIDs: []int{userID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE chat_invites SET uses = uses + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $1;",
+		invite.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO chat_invite_uses (invite_id, user_id) VALUES ($1, $2);",
+		invite.ID,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	invite.Uses++
+	return invite, nil
+}
+
+func checkInviteUsable(invite *models.ChatInvite, now time.Time) error {
+	if invite.RevokedAt.Valid {
+		return InviteRevokedErr
+	}
+	if invite.ExpiresAt.Valid && !invite.ExpiresAt.Time.After(now) {
+		return InviteExpiredErr
+	}
+	if invite.MaxUses != nil && invite.Uses >= *invite.MaxUses {
+		return InviteUsedUpErr
+	}
+	return nil
+}
+
+func newInviteCode() (string, error) {
+	code := make([]byte, inviteCodeLength)
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func NewInviteService(db *Database) *InviteService {
+	return &InviteService{db: db}
+}
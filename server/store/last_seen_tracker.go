@@ -0,0 +1,92 @@
+package store
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// lastSeenTouchInterval is the minimum time between two writes caused by REST calls of the same user.
+const lastSeenTouchInterval = time.Minute
+
+// entries are only swept once the tracker grows past this size, like in utils.RateLimiter
+const lastSeenSweepSize = 1024
+
+type LastSeenTrackerInterface interface {
+	Touch(userID int)
+	RecordLastSeen(userID int)
+}
+
+// LastSeenTracker keeps users.last_seen_at up to date. Writes happen in the background so they never
+// slow down the request or socket that caused them.
+type LastSeenTracker struct {
+	db       *Database
+	interval time.Duration
+	// written holds when the last seen of each user was last written
+	written map[int]time.Time
+	lock    sync.Mutex
+	now     func() time.Time
+}
+
+// Touch records that the user was active, it is throttled so frequent calls hit the database at most once per interval.
+func (t *LastSeenTracker) Touch(userID int) {
+	now := t.now()
+	if !t.reserve(userID, now) {
+		return
+	}
+	go t.write(userID, now)
+}
+
+// reserve reports whether the user's last seen should be written at now, when it should the write is remembered.
+func (t *LastSeenTracker) reserve(userID int, now time.Time) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if last, found := t.written[userID]; found && now.Sub(last) < t.interval {
+		return false
+	}
+	if len(t.written) >= lastSeenSweepSize {
+		t.sweep(now)
+	}
+	t.written[userID] = now
+	return true
+}
+
+// sweep drops writes older than the interval, they no longer throttle anything.
+func (t *LastSeenTracker) sweep(now time.Time) {
+	for userID, last := range t.written {
+		if now.Sub(last) >= t.interval {
+			delete(t.written, userID)
+		}
+	}
+}
+
+// RecordLastSeen always writes the current time, it is used when the user's last socket closes.
+func (t *LastSeenTracker) RecordLastSeen(userID int) {
+	now := t.now()
+	t.lock.Lock()
+	t.written[userID] = now
+	t.lock.Unlock()
+	go t.write(userID, now)
+}
+
+func (t *LastSeenTracker) write(userID int, seenAt time.Time) {
+	// writes can land out of order, GREATEST keeps the newest one
+	_, err := t.db.Exec(
+		"UPDATE users SET last_seen_at = GREATEST(last_seen_at, $1) WHERE id = $2;",
+		seenAt.UTC(),
+		userID,
+	)
+	if err != nil {
+		slog.Error("could not record last seen", "userID", userID, "error", err)
+	}
+}
+
+func NewLastSeenTracker(db *Database) *LastSeenTracker {
+	return &LastSeenTracker{
+		db:       db,
+		interval: lastSeenTouchInterval,
+		written:  make(map[int]time.Time),
+		lock:     sync.Mutex{},
+		now:      time.Now,
+	}
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastSeenTracker_ThrottlesWritesPerUser(t *testing.T) {
+	now := time.Date(2024, 7, 22, 12, 0, 0, 0, time.UTC)
+	tracker := NewLastSeenTracker(nil)
+
+	if !tracker.reserve(1, now) {
+		t.Fatalf("Expected first write to be allowed")
+	}
+
+	now = now.Add(30 * time.Second)
+	if tracker.reserve(1, now) {
+		t.Errorf("Expected write within interval to be skipped")
+	}
+	if !tracker.reserve(2, now) {
+		t.Errorf("Expected other user not to be throttled")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !tracker.reserve(1, now) {
+		t.Errorf("Expected write after interval to be allowed")
+	}
+}
+
+func TestLastSeenTracker_SweepsOldWrites(t *testing.T) {
+	now := time.Date(2024, 7, 22, 12, 0, 0, 0, time.UTC)
+	tracker := NewLastSeenTracker(nil)
+
+	for userID := 0; userID < lastSeenSweepSize; userID++ {
+		tracker.reserve(userID, now)
+	}
+
+	now = now.Add(lastSeenTouchInterval)
+	tracker.reserve(lastSeenSweepSize, now)
+
+	if len(tracker.written) != 1 {
+		t.Errorf("Expected old writes to be swept, got %d entries", len(tracker.written))
+	}
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"fmt"
+	"github.com/kacperhemperek/discord-go/utils"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails to users, swap the implementation to send them through a real provider.
+type Mailer interface {
+	Send(email *Email) error
+}
+
+// LogMailer doesn't send anything, it appends emails to the outbox file set by MAIL_OUTBOX
+// so they can be read during development.
+type LogMailer struct {
+	path string
+	lock sync.Mutex
+}
+
+func (m *LogMailer) Send(email *Email) error {
+	defer utils.LogServiceCall("LogMailer", "Send", time.Now())
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(
+		f,
+		"Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().UTC().Format(time.RFC1123Z),
+		email.To,
+		email.Subject,
+		email.Body,
+	)
+	if err != nil {
+		return err
+	}
+	slog.Info("email written to outbox", "to", email.To, "subject", email.Subject, "outbox", m.path)
+	return nil
+}
+
+func NewLogMailer() *LogMailer {
+	path := os.Getenv("MAIL_OUTBOX")
+	if path == "" {
+		path = "mail_outbox.log"
+	}
+	return &LogMailer{path: path}
+}
@@ -29,7 +29,7 @@ func (s *MessageService) CreateMessageInChat(chatID, userID int, text string) (*
 
 	row := tx.QueryRow(`
 		INSERT INTO messages (text, sender_id, chat_id) 
-			VALUES ($1, $2, $3) RETURNING id, text, created_at, updated_at;`,
+			VALUES ($1, $2, $3) RETURNING id, text, kind, system_data, created_at, updated_at;`,
 		text,
 		userID,
 		chatID,
@@ -57,11 +57,11 @@ func (s *MessageService) CreateMessageInChat(chatID, userID int, text string) (*
 
 func (s *MessageService) EnrichMessageWithUser(message *models.Message) (*models.MessageWithUser, error) {
 	row := s.db.QueryRow(`
-		SELECT u.id, u.username, u.email, u.active, u.password, u.created_at, u.updated_at 
+		SELECT u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color 
 			FROM messages m JOIN users u on u.id = m.sender_id WHERE m.id = $1`,
 		message.ID,
 	)
-	user, err := scanUser(row)
+	user, err := scanPublicUser(row)
 	if err != nil {
 		return nil, err
 	}
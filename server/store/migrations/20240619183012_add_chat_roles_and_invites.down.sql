@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "chat_invite_uses_invite_index";
+DROP INDEX IF EXISTS "chat_invite_code_index";
+
+DROP TABLE IF EXISTS "chat_invite_uses";
+DROP TABLE IF EXISTS "chat_invites";
+
+ALTER TABLE chat_to_user DROP COLUMN IF EXISTS "role";
+
+DROP TYPE IF EXISTS chat_role;
+
+COMMIT;
@@ -0,0 +1,53 @@
+BEGIN;
+
+CREATE TYPE "chat_role" AS ENUM ('owner', 'admin', 'member');
+
+ALTER TABLE chat_to_user
+    ADD COLUMN "role" chat_role NOT NULL DEFAULT 'member';
+
+-- existing group chats get their earliest member as the owner so admin features work on them
+UPDATE chat_to_user cu
+SET "role" = 'owner'
+FROM (
+    SELECT DISTINCT ON (ctu.chat_id) ctu.chat_id, ctu.user_id
+    FROM chat_to_user ctu JOIN chats c ON c.id = ctu.chat_id
+    WHERE c.type = 'group'
+    ORDER BY ctu.chat_id, ctu.created_at, ctu.user_id
+) first_member
+WHERE cu.chat_id = first_member.chat_id AND cu.user_id = first_member.user_id;
+
+CREATE TABLE IF NOT EXISTS "chat_invites" (
+    "id" SERIAL PRIMARY KEY,
+
+    "code" TEXT NOT NULL,
+    "chat_id" INTEGER NOT NULL,
+    "creator_id" INTEGER NOT NULL,
+    "max_uses" INTEGER,
+    "uses" INTEGER NOT NULL DEFAULT 0,
+    "expires_at" TIMESTAMP(3),
+    "revoked_at" TIMESTAMP(3),
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY ("chat_id") REFERENCES "chats" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("creator_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "chat_invite_uses" (
+    "id" SERIAL PRIMARY KEY,
+
+    "invite_id" INTEGER NOT NULL,
+    "user_id" INTEGER NOT NULL,
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY ("invite_id") REFERENCES "chat_invites" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX "chat_invite_code_index" ON "chat_invites"("code");
+
+CREATE INDEX "chat_invite_uses_invite_index" ON "chat_invite_uses"("invite_id");
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+DROP VIEW IF EXISTS "chat_members";
+
+DELETE FROM chats WHERE server_id IS NOT NULL;
+
+DROP INDEX IF EXISTS "chats_server_id_index";
+
+ALTER TABLE chats DROP COLUMN IF EXISTS "server_id";
+
+DROP INDEX IF EXISTS "server_member_connection";
+
+DROP TABLE IF EXISTS "server_members";
+DROP TABLE IF EXISTS "servers";
+
+COMMIT;
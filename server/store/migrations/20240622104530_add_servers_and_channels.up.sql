@@ -0,0 +1,43 @@
+ALTER TYPE chat_type ADD VALUE IF NOT EXISTS 'channel';
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "servers" (
+    "id" SERIAL PRIMARY KEY,
+
+    "name" TEXT NOT NULL,
+    "icon_url" TEXT,
+    "owner_id" INTEGER NOT NULL,
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY ("owner_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "server_members" (
+    "server_id" INTEGER NOT NULL,
+    "user_id" INTEGER NOT NULL,
+    "role" chat_role NOT NULL DEFAULT 'member',
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY ("server_id") REFERENCES "servers" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX "server_member_connection" ON "server_members"("server_id", "user_id");
+
+ALTER TABLE chats
+    ADD COLUMN "server_id" INTEGER REFERENCES "servers" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "chats_server_id_index" ON "chats"("server_id");
+
+-- channels don't have chat_to_user rows, every server member is a member of all of its channels
+CREATE VIEW "chat_members" AS
+    SELECT cu.chat_id, cu.user_id, cu.role FROM chat_to_user cu
+    UNION ALL
+    SELECT c.id, sm.user_id, sm.role FROM chats c JOIN server_members sm ON sm.server_id = c.server_id;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "chat_user_settings";
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "chat_user_settings" (
+    "chat_id" INTEGER NOT NULL,
+    "user_id" INTEGER NOT NULL,
+
+    "muted_until" TIMESTAMP(3),
+    "archived" BOOLEAN NOT NULL DEFAULT false,
+    "favourite" BOOLEAN NOT NULL DEFAULT false,
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY ("chat_id", "user_id"),
+    FOREIGN KEY ("chat_id") REFERENCES "chats" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+COMMIT;
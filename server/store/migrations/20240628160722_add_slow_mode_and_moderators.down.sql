@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE chats DROP COLUMN IF EXISTS "slow_mode_seconds";
+
+UPDATE chat_to_user SET role = 'member' WHERE role = 'moderator';
+UPDATE server_members SET role = 'member' WHERE role = 'moderator';
+
+COMMIT;
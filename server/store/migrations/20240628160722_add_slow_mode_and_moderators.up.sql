@@ -0,0 +1,8 @@
+ALTER TYPE chat_role ADD VALUE IF NOT EXISTS 'moderator';
+
+BEGIN;
+
+ALTER TABLE chats
+    ADD COLUMN "slow_mode_seconds" INTEGER NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE chats
+    DROP COLUMN IF EXISTS "icon_url",
+    DROP COLUMN IF EXISTS "topic",
+    DROP COLUMN IF EXISTS "description";
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE chats
+    ADD COLUMN "description" VARCHAR(1024),
+    ADD COLUMN "topic"       VARCHAR(256),
+    ADD COLUMN "icon_url"    TEXT;
+
+COMMIT;
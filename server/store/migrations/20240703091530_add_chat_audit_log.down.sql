@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS chat_audit_log_append_only ON chat_audit_log;
+DROP FUNCTION IF EXISTS prevent_chat_audit_log_changes;
+DROP TABLE IF EXISTS chat_audit_log;
+DROP TYPE IF EXISTS chat_audit_action;
+
+COMMIT;
@@ -0,0 +1,46 @@
+BEGIN;
+
+CREATE TYPE "chat_audit_action" AS ENUM (
+    'chat_updated',
+    'members_added',
+    'member_kicked',
+    'member_role_updated',
+    'slow_mode_updated',
+    'ownership_transferred',
+    'invite_revoked'
+);
+
+CREATE TABLE IF NOT EXISTS "chat_audit_log" (
+    "id" SERIAL PRIMARY KEY,
+
+    "chat_id" INTEGER NOT NULL,
+    "actor_id" INTEGER,
+    "action" chat_audit_action NOT NULL,
+    "target_user_id" INTEGER,
+    "before" JSONB,
+    "after" JSONB,
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY ("chat_id") REFERENCES "chats" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("actor_id") REFERENCES "users" ("id") ON DELETE SET NULL,
+    FOREIGN KEY ("target_user_id") REFERENCES "users" ("id") ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS "chat_audit_log_chat_id_idx" ON "chat_audit_log" ("chat_id", "id" DESC);
+
+-- entries can't be changed directly, only the foreign key cascades are allowed to touch them
+CREATE OR REPLACE FUNCTION prevent_chat_audit_log_changes() RETURNS TRIGGER AS $$
+BEGIN
+    IF pg_trigger_depth() > 1 THEN
+        RETURN COALESCE(NEW, OLD);
+    END IF;
+    RAISE EXCEPTION 'chat_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "chat_audit_log_append_only"
+    BEFORE UPDATE OR DELETE ON "chat_audit_log"
+    FOR EACH ROW EXECUTE FUNCTION prevent_chat_audit_log_changes();
+
+COMMIT;
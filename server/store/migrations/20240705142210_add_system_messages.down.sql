@@ -0,0 +1,11 @@
+BEGIN;
+
+DELETE FROM messages WHERE kind = 'system';
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS "system_data",
+    DROP COLUMN IF EXISTS "kind";
+
+DROP TYPE IF EXISTS "message_kind";
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+CREATE TYPE "message_kind" AS ENUM ('user', 'system');
+
+ALTER TABLE messages
+    ADD COLUMN "kind" message_kind NOT NULL DEFAULT 'user',
+    ADD COLUMN "system_data" JSONB;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DELETE FROM "notifications" WHERE "type" = 'friend_request_accepted';
+
+COMMIT;
@@ -0,0 +1,8 @@
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'friend_request_accepted';
+
+BEGIN;
+
+-- friend request notifications created before the payload carried the inviter can't be shown
+DELETE FROM "notifications" WHERE "type" = 'friend_request' AND NOT ("data" ? 'inviter');
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "user_handle_index";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "handle";
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+ALTER TABLE "users" ADD COLUMN "handle" TEXT;
+
+-- existing users get a handle derived from their username, the id suffix keeps it unique
+UPDATE "users"
+SET "handle" = COALESCE(NULLIF(LEFT(LOWER(REGEXP_REPLACE("username", '[^a-zA-Z0-9_.]', '', 'g')), 20), ''), 'user') || '_' || "id";
+
+ALTER TABLE "users" ALTER COLUMN "handle" SET NOT NULL;
+
+CREATE UNIQUE INDEX "user_handle_index" ON "users"(LOWER("handle"));
+
+COMMIT;
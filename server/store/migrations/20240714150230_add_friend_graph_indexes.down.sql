@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "chat_to_user_user_id_idx";
+DROP INDEX IF EXISTS "friendships_friend_id_idx";
+DROP INDEX IF EXISTS "friendships_accepted_friend_idx";
+DROP INDEX IF EXISTS "friendships_accepted_inviter_idx";
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- mutual friends and suggestions walk accepted friendships from both sides
+CREATE INDEX IF NOT EXISTS "friendships_accepted_inviter_idx" ON "friendships" ("inviter_id", "friend_id") WHERE "status" = 'accepted';
+CREATE INDEX IF NOT EXISTS "friendships_accepted_friend_idx" ON "friendships" ("friend_id", "inviter_id") WHERE "status" = 'accepted';
+CREATE INDEX IF NOT EXISTS "friendships_friend_id_idx" ON "friendships" ("friend_id");
+
+CREATE INDEX IF NOT EXISTS "chat_to_user_user_id_idx" ON "chat_to_user" ("user_id", "chat_id");
+
+COMMIT;
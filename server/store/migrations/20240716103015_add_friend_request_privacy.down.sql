@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "friend_request_privacy";
+
+DROP TYPE IF EXISTS "friend_request_privacy";
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+CREATE TYPE "friend_request_privacy" AS ENUM ('everyone', 'friends_of_friends', 'nobody');
+
+ALTER TABLE "users" ADD COLUMN "friend_request_privacy" friend_request_privacy NOT NULL DEFAULT 'everyone';
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "friend_profiles" (
+    "owner_id" INTEGER NOT NULL,
+    "friend_id" INTEGER NOT NULL,
+
+    "nickname" VARCHAR(32),
+    "note" VARCHAR(1024),
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY ("owner_id", "friend_id"),
+    FOREIGN KEY ("owner_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("friend_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+COMMIT;
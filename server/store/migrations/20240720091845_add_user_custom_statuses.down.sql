@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "user_custom_statuses";
+
+COMMIT;
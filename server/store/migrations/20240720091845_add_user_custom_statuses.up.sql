@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "user_custom_statuses" (
+    "user_id" INTEGER PRIMARY KEY,
+
+    "text" VARCHAR(128),
+    "emoji" VARCHAR(64),
+    "expires_at" TIMESTAMP(3),
+    "do_not_disturb" BOOLEAN NOT NULL DEFAULT false,
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "user_custom_statuses_expires_at_idx" ON "user_custom_statuses" ("expires_at") WHERE "expires_at" IS NOT NULL;
+
+COMMIT;
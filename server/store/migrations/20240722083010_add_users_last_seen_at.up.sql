@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "users" ADD COLUMN "last_seen_at" TIMESTAMP(3) NULL;
+
+ALTER TABLE "users" ADD COLUMN "show_last_seen" BOOLEAN NOT NULL DEFAULT TRUE;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "banner_color";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "avatar";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "bio";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "display_name";
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE "users" ADD COLUMN "display_name" VARCHAR(32) NULL;
+
+ALTER TABLE "users" ADD COLUMN "bio" VARCHAR(190) NULL;
+
+ALTER TABLE "users" ADD COLUMN "avatar" JSONB NULL;
+
+ALTER TABLE "users" ADD COLUMN "banner_color" VARCHAR(7) NULL;
+
+COMMIT;
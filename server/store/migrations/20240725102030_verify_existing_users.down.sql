@@ -0,0 +1,5 @@
+BEGIN;
+
+-- Which accounts were verified by the up migration is not recorded, so there is nothing to undo.
+
+COMMIT;
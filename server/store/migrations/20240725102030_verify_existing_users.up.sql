@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Accounts created before email verification was introduced never got a verification link,
+-- they are trusted as verified so their owners aren't locked out of sending friend requests.
+UPDATE "users" SET "active" = TRUE WHERE "active" = FALSE;
+
+COMMIT;
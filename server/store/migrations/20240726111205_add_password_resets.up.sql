@@ -0,0 +1,22 @@
+BEGIN;
+
+ALTER TABLE "users" ADD COLUMN "session_version" INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "password_reset_tokens" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INTEGER NOT NULL,
+
+    "token_hash" VARCHAR(64) NOT NULL,
+    "expires_at" TIMESTAMP(3) NOT NULL,
+    "used_at" TIMESTAMP(3),
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "password_reset_token_hash_index" ON "password_reset_tokens" ("token_hash");
+
+CREATE INDEX IF NOT EXISTS "password_reset_user_id_index" ON "password_reset_tokens" ("user_id");
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE messages
+    DROP CONSTRAINT messages_sender_id_fkey;
+
+ALTER TABLE messages
+    ADD CONSTRAINT messages_sender_id_fkey
+        FOREIGN KEY ("sender_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+DELETE FROM "users" WHERE "is_deleted_placeholder";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "is_deleted_placeholder";
+
+COMMIT;
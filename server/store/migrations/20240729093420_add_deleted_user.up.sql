@@ -0,0 +1,30 @@
+BEGIN;
+
+ALTER TABLE "users" ADD COLUMN "is_deleted_placeholder" BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX "user_deleted_placeholder_index" ON "users"("is_deleted_placeholder") WHERE "is_deleted_placeholder";
+
+-- messages of deleted accounts are re-attributed to this user, the password can't be used to log in and
+-- the user can't be looked up, befriended or blocked. A random suffix is used when someone already took the handle or email.
+INSERT INTO "users" ("username", "handle", "email", "password", "active", "is_deleted_placeholder")
+SELECT
+    'Deleted User',
+    CASE WHEN EXISTS (SELECT 1 FROM "users" WHERE LOWER("handle") = 'deleted-user')
+        THEN 'deleted-user_' || SUBSTR(MD5(RANDOM()::TEXT), 1, 8)
+        ELSE 'deleted-user'
+    END,
+    CASE WHEN EXISTS (SELECT 1 FROM "users" WHERE "email" = 'deleted-user@invalid')
+        THEN 'deleted-user-' || SUBSTR(MD5(RANDOM()::TEXT), 1, 8) || '@invalid'
+        ELSE 'deleted-user@invalid'
+    END,
+    '!',
+    false;
+
+ALTER TABLE messages
+    DROP CONSTRAINT messages_sender_id_fkey;
+
+ALTER TABLE messages
+    ADD CONSTRAINT messages_sender_id_fkey
+        FOREIGN KEY ("sender_id") REFERENCES "users" ("id");
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "data_exports";
+
+DROP TYPE IF EXISTS "data_export_status";
+
+COMMIT;
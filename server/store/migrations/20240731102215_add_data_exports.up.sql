@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TYPE "data_export_status" AS ENUM ('pending', 'ready', 'failed');
+
+CREATE TABLE IF NOT EXISTS "data_exports" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INTEGER NOT NULL,
+
+    "status" data_export_status NOT NULL DEFAULT 'pending',
+    "file_name" TEXT,
+
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "completed_at" TIMESTAMP(3),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+-- a user can only have one export being built at a time
+CREATE UNIQUE INDEX IF NOT EXISTS "data_exports_pending_index" ON "data_exports" ("user_id") WHERE "status" = 'pending';
+
+CREATE INDEX IF NOT EXISTS "data_exports_user_id_index" ON "data_exports" ("user_id");
+
+COMMIT;
@@ -18,10 +18,14 @@ import (
 type NotificationServiceInterface interface {
 	GetUserFriendRequestNotifications(userID int, seen *BoolFilter, limit *LimitFilter) ([]*models.FriendRequestNotification, error)
 	GetUserNewMessageNotifications(userID int, seen *BoolFilter, limit *LimitFilter) ([]*models.NewMessageNotification, error)
+	GetUserFriendRequestAcceptedNotifications(userID int, seen *BoolFilter, limit *LimitFilter) ([]*models.FriendRequestAcceptedNotification, error)
 
 	CreateFriendRequestNotification(userID int, data models.FriendRequestNotificationData) (*models.FriendRequestNotification, error)
+	CreateFriendRequestAcceptedNotification(userID int, data *models.FriendRequestAcceptedNotificationData) (*models.FriendRequestAcceptedNotification, error)
 	CreateNewMessageNotificationsForUsers(userIDs []int, data *models.NewMessageNotificationData) ([]*models.NewMessageNotification, error)
 
+	DeleteFriendRequestNotifications(userID, friendshipID int) ([]int, error)
+
 	MarkUsersNotificationsAsSeen(userID int, nType string) error
 	MarkUsersNewMessageNotificationsAsSeenByChatID(userID, chatID int) error
 }
@@ -76,6 +80,51 @@ func (s *NotificationService) CreateFriendRequestNotification(userID int, data m
 	}, nil
 }
 
+// CreateFriendRequestAcceptedNotification notifies the inviter that the friend request they sent was accepted.
+func (s *NotificationService) CreateFriendRequestAcceptedNotification(userID int, data *models.FriendRequestAcceptedNotificationData) (*models.FriendRequestAcceptedNotification, error) {
+	defer utils.LogServiceCall("NotificationsService", "CreateFriendRequestAcceptedNotification", time.Now())
+	if err := s.validator.Struct(data); err != nil {
+		return nil, err
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	fran := types.FriendRequestAcceptedNotification
+	row := s.db.QueryRow(
+		"INSERT INTO notifications (user_id, type, data) VALUES ($1, $2, $3) RETURNING id, type, user_id, data, seen, created_at, updated_at;",
+		userID,
+		fran.String(),
+		jsonData,
+	)
+	return scanFriendRequestAcceptedNotification(row)
+}
+
+// DeleteFriendRequestNotifications removes the user's notifications about the friend request and returns their ids.
+func (s *NotificationService) DeleteFriendRequestNotifications(userID, friendshipID int) ([]int, error) {
+	defer utils.LogServiceCall("NotificationsService", "DeleteFriendRequestNotifications", time.Now())
+	frn := types.FriendRequestNotification
+	rows, err := s.db.Query(
+		"DELETE FROM notifications WHERE type = $1 AND user_id = $2 AND (data->>'friendshipId')::int = $3 RETURNING id;",
+		frn.String(),
+		userID,
+		friendshipID,
+	)
+	ids := make([]int, 0)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		id, err := scanID(rows)
+		if err != nil {
+			return make([]int, 0), err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 func (s *NotificationService) GetUserFriendRequestNotifications(
 	userID int,
 	seen *BoolFilter,
@@ -228,6 +277,47 @@ func (s *NotificationService) GetUserNewMessageNotifications(userID int, seen *B
 	return ns, nil
 }
 
+// GetUserFriendRequestAcceptedNotifications returns notifications about the user's friend requests
+// that were accepted, newest first.
+func (s *NotificationService) GetUserFriendRequestAcceptedNotifications(userID int, seen *BoolFilter, limit *LimitFilter) ([]*models.FriendRequestAcceptedNotification, error) {
+	defer utils.LogServiceCall("NotificationsService", "GetUserFriendRequestAcceptedNotifications", time.Now())
+	fran := types.FriendRequestAcceptedNotification
+	where := []string{"type = @type", "user_id = @user_id"}
+	args := pgx.NamedArgs{
+		"type":    fran.String(),
+		"user_id": userID,
+	}
+	if seen != nil {
+		where = append(where, "seen = @seen")
+		args["seen"] = seen
+	}
+	limitSQL := ""
+	if limit != nil {
+		limitSQL = fmt.Sprintf(" LIMIT %d", *limit)
+	}
+	rows, err := s.db.Query(
+		"SELECT id, type, user_id, data, seen, created_at, updated_at FROM notifications "+
+			whereSQL(where)+
+			" ORDER BY created_at DESC"+
+			limitSQL+
+			";",
+		args,
+	)
+	notifications := make([]*models.FriendRequestAcceptedNotification, 0)
+	if err != nil {
+		return notifications, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		n, err := scanFriendRequestAcceptedNotification(rows)
+		if err != nil {
+			return make([]*models.FriendRequestAcceptedNotification, 0), err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
 func (s *NotificationService) MarkUsersNewMessageNotificationsAsSeenByChatID(userID, chatID int) error {
 	tx, err := s.db.Begin()
 	defer func() {
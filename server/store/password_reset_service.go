@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/kacperhemperek/discord-go/utils"
+	"time"
+)
+
+// passwordResetTokenTTL is how long a reset link stays valid.
+const passwordResetTokenTTL = time.Hour
+
+var (
+	InvalidResetTokenErr = errors.New("password reset token is invalid, used or expired")
+)
+
+type PasswordResetServiceInterface interface {
+	CreateResetToken(userID int) (string, error)
+	ResetPassword(token, hashedPassword string) error
+}
+
+// PasswordResetService hands out single use password reset tokens, only their hashes are stored
+// so a leaked database can't be used to take over accounts.
+type PasswordResetService struct {
+	db *Database
+}
+
+// CreateResetToken returns a new reset token for the user, tokens created earlier stop working.
+func (s *PasswordResetService) CreateResetToken(userID int) (string, error) {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("PasswordResetService", "CreateResetToken", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return "", err
+	}
+	token, err := utils.NewRandomToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	_, err = tx.Exec(
+		"UPDATE password_reset_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL;",
+		now,
+		userID,
+	)
+	if err != nil {
+		return "", err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3);",
+		userID,
+		utils.HashToken(token),
+		now.Add(passwordResetTokenTTL),
+	)
+	if err != nil {
+		return "", err
+	}
+	return token, tx.Commit()
+}
+
+// ResetPassword uses up the token and sets the new password of the user it was created for in one transaction,
+// so a token is never spent without the password changing. All of the user's sessions are revoked.
+func (s *PasswordResetService) ResetPassword(token, hashedPassword string) error {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("PasswordResetService", "ResetPassword", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	userID, err := scanID(tx.QueryRow(
+		"UPDATE password_reset_tokens SET used_at = $1 WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1 RETURNING user_id;",
+		now,
+		utils.HashToken(token),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return InvalidResetTokenErr
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE users SET password = $1, session_version = session_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;",
+		hashedPassword,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func NewPasswordResetService(db *Database) *PasswordResetService {
+	return &PasswordResetService{db: db}
+}
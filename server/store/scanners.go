@@ -1,6 +1,7 @@
 package store
 
 import (
+	"database/sql"
 	"encoding/json"
 	"github.com/kacperhemperek/discord-go/models"
 )
@@ -18,6 +19,11 @@ func scanUser(rows Scanner) (*models.User, error) {
 	err := rows.Scan(
 		&user.ID,
 		&user.Username,
+		&user.Handle,
+		&user.DisplayName,
+		&user.Bio,
+		&user.Avatar,
+		&user.BannerColor,
 		&user.Email,
 		&user.Active,
 		&user.Password,
@@ -32,27 +38,146 @@ func scanUser(rows Scanner) (*models.User, error) {
 	return user, nil
 }
 
+// scanPublicUser scans the id, username, handle and profile columns of a user other than the logged-in one.
+func scanPublicUser(scanner Scanner) (*models.PublicUser, error) {
+	user := &models.PublicUser{}
+	err := scanner.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Handle,
+		&user.DisplayName,
+		&user.Bio,
+		&user.Avatar,
+		&user.BannerColor,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 func scanFriend(rows Scanner) (*models.Friend, error) {
 	friend := &models.Friend{}
+	customStatus := &nullableCustomStatus{}
 
 	err := rows.Scan(
 		&friend.ID,
 		&friend.Username,
-		&friend.Email,
-		&friend.Active,
-		&friend.Password,
-		&friend.CreatedAt,
-		&friend.UpdatedAt,
+		&friend.Handle,
+		&friend.DisplayName,
+		&friend.Bio,
+		&friend.Avatar,
+		&friend.BannerColor,
 		&friend.AcceptedAt,
+		&friend.Nickname,
+		&customStatus.text,
+		&customStatus.emoji,
+		&customStatus.expiresAt,
+		&customStatus.doNotDisturb,
 	)
 
 	if err != nil {
 		return nil, err
 	}
+	friend.CustomStatus = customStatus.value()
 
 	return friend, nil
 }
 
+func scanFriendSuggestion(scanner Scanner) (*models.FriendSuggestion, error) {
+	suggestion := &models.FriendSuggestion{
+		User: &models.PublicUser{},
+	}
+	err := scanner.Scan(
+		&suggestion.User.ID,
+		&suggestion.User.Username,
+		&suggestion.User.Handle,
+		&suggestion.MutualFriends,
+		&suggestion.SharedChats,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return suggestion, nil
+}
+
+func scanChatMember(scanner Scanner) (*models.PublicUser, error) {
+	user := &models.PublicUser{}
+	customStatus := &nullableCustomStatus{}
+	err := scanner.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Handle,
+		&user.DisplayName,
+		&user.Bio,
+		&user.Avatar,
+		&user.BannerColor,
+		&user.Nickname,
+		&customStatus.text,
+		&customStatus.emoji,
+		&customStatus.expiresAt,
+		&customStatus.doNotDisturb,
+	)
+	if err != nil {
+		return nil, err
+	}
+	user.CustomStatus = customStatus.value()
+	return user, nil
+}
+
+// nullableCustomStatus scans a custom status that was LEFT JOINed, the status is nil when the user has none.
+type nullableCustomStatus struct {
+	text         sql.NullString
+	emoji        sql.NullString
+	expiresAt    models.NullTime
+	doNotDisturb sql.NullBool
+}
+
+func (s *nullableCustomStatus) value() *models.CustomStatus {
+	if !s.doNotDisturb.Valid {
+		return nil
+	}
+	status := &models.CustomStatus{
+		ExpiresAt:    s.expiresAt,
+		DoNotDisturb: s.doNotDisturb.Bool,
+	}
+	if s.text.Valid {
+		status.Text = &s.text.String
+	}
+	if s.emoji.Valid {
+		status.Emoji = &s.emoji.String
+	}
+	return status
+}
+
+func scanCustomStatus(scanner Scanner) (*models.CustomStatus, error) {
+	status := &models.CustomStatus{}
+	err := scanner.Scan(
+		&status.Text,
+		&status.Emoji,
+		&status.ExpiresAt,
+		&status.DoNotDisturb,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func scanFriendProfile(scanner Scanner) (*models.FriendProfile, error) {
+	profile := &models.FriendProfile{}
+	err := scanner.Scan(
+		&profile.FriendID,
+		&profile.Nickname,
+		&profile.Note,
+		&profile.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
 // Scans friendship from the given Scanner, this function accepts sql.Rows and sql.Row as Scanner
 // and returns a friendship or an error if the scan fails
 func scanFriendship(row Scanner) (*models.Friendship, error) {
@@ -79,7 +204,7 @@ func scanFriendship(row Scanner) (*models.Friendship, error) {
 // and returns a FriendRequest and an error if the scan fails
 func scanFriendRequest(s Scanner) (*models.FriendRequest, error) {
 	friendRequest := &models.FriendRequest{
-		User: &models.User{},
+		User: &models.PublicUser{},
 	}
 
 	err := s.Scan(
@@ -89,11 +214,11 @@ func scanFriendRequest(s Scanner) (*models.FriendRequest, error) {
 		&friendRequest.StatusChangedAt,
 		&friendRequest.User.ID,
 		&friendRequest.User.Username,
-		&friendRequest.User.Email,
-		&friendRequest.User.Active,
-		&friendRequest.User.Password,
-		&friendRequest.User.CreatedAt,
-		&friendRequest.User.UpdatedAt,
+		&friendRequest.User.Handle,
+		&friendRequest.User.DisplayName,
+		&friendRequest.User.Bio,
+		&friendRequest.User.Avatar,
+		&friendRequest.User.BannerColor,
 	)
 
 	if err != nil {
@@ -110,6 +235,11 @@ func scanChat(scanner Scanner) (*models.Chat, error) {
 		&chat.ID,
 		&chat.Name,
 		&chat.Type,
+		&chat.ServerID,
+		&chat.SlowModeSeconds,
+		&chat.Description,
+		&chat.Topic,
+		&chat.IconURL,
 		&chat.CreatedAt,
 		&chat.UpdatedAt,
 	)
@@ -121,11 +251,50 @@ func scanChat(scanner Scanner) (*models.Chat, error) {
 	return chat, nil
 }
 
+func scanChatSettings(scanner Scanner) (*models.ChatSettings, error) {
+	settings := &models.ChatSettings{}
+	err := scanner.Scan(
+		&settings.MutedUntil,
+		&settings.Archived,
+		&settings.Favourite,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func scanChatWithSettings(scanner Scanner) (*models.Chat, *models.ChatSettings, error) {
+	chat := &models.Chat{}
+	settings := &models.ChatSettings{}
+	err := scanner.Scan(
+		&chat.ID,
+		&chat.Name,
+		&chat.Type,
+		&chat.ServerID,
+		&chat.SlowModeSeconds,
+		&chat.Description,
+		&chat.Topic,
+		&chat.IconURL,
+		&chat.CreatedAt,
+		&chat.UpdatedAt,
+		&settings.MutedUntil,
+		&settings.Archived,
+		&settings.Favourite,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return chat, settings, nil
+}
+
 func scanMessage(scanner Scanner) (*models.Message, error) {
 	message := &models.Message{}
 	err := scanner.Scan(
 		&message.ID,
 		&message.Text,
+		&message.Kind,
+		&message.System,
 		&message.CreatedAt,
 		&message.UpdatedAt,
 	)
@@ -137,20 +306,23 @@ func scanMessage(scanner Scanner) (*models.Message, error) {
 
 func scanMessageWithUser(scanner Scanner) (*models.MessageWithUser, error) {
 	message := &models.MessageWithUser{
-		User: &models.User{},
+		User: &models.PublicUser{},
 	}
 	err := scanner.Scan(
 		&message.ID,
 		&message.Text,
+		&message.Kind,
+		&message.System,
 		&message.CreatedAt,
 		&message.UpdatedAt,
 		&message.User.ID,
 		&message.User.Username,
-		&message.User.Email,
-		&message.User.Active,
-		&message.User.Password,
-		&message.User.CreatedAt,
-		&message.User.UpdatedAt,
+		&message.User.Handle,
+		&message.User.DisplayName,
+		&message.User.Bio,
+		&message.User.Avatar,
+		&message.User.BannerColor,
+		&message.FromBlockedUser,
 	)
 	if err != nil {
 		return nil, err
@@ -185,6 +357,33 @@ func scanFriendRequestNotification(scanner Scanner) (*models.FriendRequestNotifi
 	}, nil
 }
 
+func scanFriendRequestAcceptedNotification(scanner Scanner) (*models.FriendRequestAcceptedNotification, error) {
+	notificationDto := &models.NotificationDTO{}
+	err := scanner.Scan(
+		&notificationDto.BaseNotification.Base.ID,
+		&notificationDto.BaseNotification.Type,
+		&notificationDto.BaseNotification.UserID,
+		&notificationDto.Data,
+		&notificationDto.BaseNotification.Seen,
+		&notificationDto.BaseNotification.Base.CreatedAt,
+		&notificationDto.BaseNotification.Base.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	notificationData := &models.FriendRequestAcceptedNotificationData{}
+	err = json.Unmarshal(notificationDto.Data, notificationData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FriendRequestAcceptedNotification{
+		BaseNotification: notificationDto.BaseNotification,
+		Data:             notificationData,
+	}, nil
+}
+
 // Scans only single entry from query that has to be an integer,
 // returns id from table or -1 and error when scan returned error
 func scanID(scanner Scanner) (int, error) {
@@ -195,3 +394,116 @@ func scanID(scanner Scanner) (int, error) {
 	}
 	return ID, nil
 }
+
+func scanChatInvite(scanner Scanner) (*models.ChatInvite, error) {
+	invite := &models.ChatInvite{}
+	err := scanner.Scan(
+		&invite.ID,
+		&invite.Code,
+		&invite.ChatID,
+		&invite.CreatorID,
+		&invite.MaxUses,
+		&invite.Uses,
+		&invite.ExpiresAt,
+		&invite.RevokedAt,
+		&invite.CreatedAt,
+		&invite.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+func scanChatInviteUse(scanner Scanner) (*models.ChatInviteUse, error) {
+	use := &models.ChatInviteUse{
+		User: &models.PublicUser{},
+	}
+	err := scanner.Scan(
+		&use.ID,
+		&use.InviteID,
+		&use.CreatedAt,
+		&use.User.ID,
+		&use.User.Username,
+		&use.User.Handle,
+		&use.User.DisplayName,
+		&use.User.Bio,
+		&use.User.Avatar,
+		&use.User.BannerColor,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return use, nil
+}
+
+func scanServer(scanner Scanner) (*models.Server, error) {
+	server := &models.Server{}
+	err := scanner.Scan(
+		&server.ID,
+		&server.Name,
+		&server.IconURL,
+		&server.OwnerID,
+		&server.CreatedAt,
+		&server.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+func scanServerMember(scanner Scanner) (*models.ServerMember, error) {
+	member := &models.ServerMember{}
+	err := scanner.Scan(
+		&member.Role,
+		&member.ID,
+		&member.Username,
+		&member.Handle,
+		&member.DisplayName,
+		&member.Bio,
+		&member.Avatar,
+		&member.BannerColor,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func scanChatAuditLogEntry(scanner Scanner) (*models.ChatAuditLogEntry, error) {
+	entry := &models.ChatAuditLogEntry{}
+	var before, after []byte
+	err := scanner.Scan(
+		&entry.ID,
+		&entry.ChatID,
+		&entry.ActorID,
+		&entry.Action,
+		&entry.TargetUserID,
+		&before,
+		&after,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entry.Before = before
+	entry.After = after
+	return entry, nil
+}
+
+func scanDataExport(scanner Scanner) (*models.DataExport, error) {
+	export := &models.DataExport{}
+	err := scanner.Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Status,
+		&export.FileName,
+		&export.CreatedAt,
+		&export.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
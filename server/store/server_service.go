@@ -0,0 +1,293 @@
+package store
+
+import (
+	"fmt"
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/types"
+	"github.com/kacperhemperek/discord-go/utils"
+	"strings"
+	"time"
+)
+
+const defaultServerChannelName = "general"
+
+type ServerServiceInterface interface {
+	CreateServer(ownerID int, name string, iconURL *string) (*models.ServerWithChannels, error)
+	GetServerByID(serverID int) (*models.Server, error)
+	GetUsersServers(userID int) ([]*models.Server, error)
+	UpdateServer(serverID int, name string, iconURL *string) (*models.Server, error)
+	DeleteServer(serverID int) ([]int, error)
+
+	GetServerMemberRole(serverID, userID int) (types.ChatRole, error)
+	GetServerMembers(serverID int) ([]*models.ServerMember, error)
+	AddServerMembers(serverID int, userIDs []int) error
+	RemoveServerMember(serverID, userID int) error
+
+	GetServerChannels(serverID int) ([]*models.Chat, error)
+	CreateChannel(serverID int, name string) (*models.Chat, error)
+	DeleteChannel(serverID, chatID int) error
+}
+
+type ServerService struct {
+	db *Database
+}
+
+// CreateServer creates the server together with the owner membership and a default channel,
+// so a freshly created server is usable straight away.
+func (s *ServerService) CreateServer(ownerID int, name string, iconURL *string) (*models.ServerWithChannels, error) {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ServerService", "CreateServer", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	row := tx.QueryRow(
+		"INSERT INTO servers (name, icon_url, owner_id) VALUES ($1, $2, $3) RETURNING id, name, icon_url, owner_id, created_at, updated_at;",
+		name,
+		iconURL,
+		ownerID,
+	)
+	server, err := scanServer(row)
+	if err != nil {
+		return nil, err
+	}
+	owner := types.OwnerChatRole
+	_, err = tx.Exec(
+		"INSERT INTO server_members (server_id, user_id, role) VALUES ($1, $2, $3);",
+		server.ID,
+		ownerID,
+		owner.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	row = tx.QueryRow(
+		"INSERT INTO chats (name, type, server_id) VALUES ($1, 'channel', $2) RETURNING id, name, type, server_id, slow_mode_seconds, description, topic, icon_url, created_at, updated_at;",
+		defaultServerChannelName,
+		server.ID,
+	)
+	channel, err := scanChat(row)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &models.ServerWithChannels{
+		Channels: []*models.Chat{channel},
+		Server:   *server,
+	}, nil
+}
+
+func (s *ServerService) GetServerByID(serverID int) (*models.Server, error) {
+	defer utils.LogServiceCall("ServerService", "GetServerByID", time.Now())
+	row := s.db.QueryRow(
+		"SELECT id, name, icon_url, owner_id, created_at, updated_at FROM servers WHERE id = $1;",
+		serverID,
+	)
+	return scanServer(row)
+}
+
+func (s *ServerService) GetUsersServers(userID int) ([]*models.Server, error) {
+	defer utils.LogServiceCall("ServerService", "GetUsersServers", time.Now())
+	rows, err := s.db.Query(`
+		SELECT s.id, s.name, s.icon_url, s.owner_id, s.created_at, s.updated_at
+			FROM servers s JOIN server_members sm ON sm.server_id = s.id
+			WHERE sm.user_id = $1 ORDER BY sm.created_at;`,
+		userID,
+	)
+	servers := make([]*models.Server, 0)
+	if err != nil {
+		return servers, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		server, err := scanServer(rows)
+		if err != nil {
+			return make([]*models.Server, 0), err
+		}
+		servers = append(servers, server)
+	}
+	return servers, rows.Err()
+}
+
+func (s *ServerService) UpdateServer(serverID int, name string, iconURL *string) (*models.Server, error) {
+	defer utils.LogServiceCall("ServerService", "UpdateServer", time.Now())
+	row := s.db.QueryRow(
+		"UPDATE servers SET name = $1, icon_url = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3 RETURNING id, name, icon_url, owner_id, created_at, updated_at;",
+		name,
+		iconURL,
+		serverID,
+	)
+	return scanServer(row)
+}
+
+// DeleteServer deletes the server with its channels and their new message notifications,
+// it returns ids of the deleted channels.
+func (s *ServerService) DeleteServer(serverID int) ([]int, error) {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ServerService", "DeleteServer", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	nmn := types.NewMessageNotification
+	_, err = tx.Exec(
+		"DELETE FROM notifications WHERE type = $1 AND (data->>'chatId')::int IN (SELECT id FROM chats WHERE server_id = $2);",
+		nmn.String(),
+		serverID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query("DELETE FROM chats WHERE server_id = $1 RETURNING id;", serverID)
+	if err != nil {
+		return nil, err
+	}
+	channelIDs := make([]int, 0)
+	for rows.Next() {
+		id, err := scanID(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		channelIDs = append(channelIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM servers WHERE id = $1;", serverID); err != nil {
+		return nil, err
+	}
+	return channelIDs, tx.Commit()
+}
+
+func (s *ServerService) GetServerMemberRole(serverID, userID int) (types.ChatRole, error) {
+	defer utils.LogServiceCall("ServerService", "GetServerMemberRole", time.Now())
+	var role types.ChatRole
+	err := s.db.QueryRow(
+		"SELECT role FROM server_members WHERE server_id = $1 AND user_id = $2;",
+		serverID,
+		userID,
+	).Scan(&role)
+	return role, err
+}
+
+func (s *ServerService) GetServerMembers(serverID int) ([]*models.ServerMember, error) {
+	defer utils.LogServiceCall("ServerService", "GetServerMembers", time.Now())
+	rows, err := s.db.Query(`
+		SELECT sm.role, u.id, u.username, u.handle, u.display_name, u.bio, u.avatar, u.banner_color
+			FROM server_members sm JOIN users u ON u.id = sm.user_id
+			WHERE sm.server_id = $1 ORDER BY sm.created_at;`,
+		serverID,
+	)
+	members := make([]*models.ServerMember, 0)
+	if err != nil {
+		return members, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		member, err := scanServerMember(rows)
+		if err != nil {
+			return make([]*models.ServerMember, 0), err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (s *ServerService) AddServerMembers(serverID int, userIDs []int) error {
+	defer utils.LogServiceCall("ServerService", "AddServerMembers", time.Now())
+	if len(userIDs) == 0 {
+		return nil
+	}
+	values := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		values[i] = fmt.Sprintf("(%d, %d)", serverID, userID)
+	}
+	_, err := s.db.Exec(
+		"INSERT INTO server_members (server_id, user_id) VALUES " + strings.Join(values, ",") + " ON CONFLICT (server_id, user_id) DO NOTHING;",
+	)
+	return err
+}
+
+func (s *ServerService) RemoveServerMember(serverID, userID int) error {
+	defer utils.LogServiceCall("ServerService", "RemoveServerMember", time.Now())
+	_, err := s.db.Exec(
+		"DELETE FROM server_members WHERE server_id = $1 AND user_id = $2;",
+		serverID,
+		userID,
+	)
+	return err
+}
+
+func (s *ServerService) GetServerChannels(serverID int) ([]*models.Chat, error) {
+	defer utils.LogServiceCall("ServerService", "GetServerChannels", time.Now())
+	rows, err := s.db.Query(
+		"SELECT id, name, type, server_id, slow_mode_seconds, description, topic, icon_url, created_at, updated_at FROM chats WHERE server_id = $1 ORDER BY created_at;",
+		serverID,
+	)
+	channels := make([]*models.Chat, 0)
+	if err != nil {
+		return channels, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		channel, err := scanChat(rows)
+		if err != nil {
+			return make([]*models.Chat, 0), err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, rows.Err()
+}
+
+func (s *ServerService) CreateChannel(serverID int, name string) (*models.Chat, error) {
+	defer utils.LogServiceCall("ServerService", "CreateChannel", time.Now())
+	row := s.db.QueryRow(
+		"INSERT INTO chats (name, type, server_id) VALUES ($1, 'channel', $2) RETURNING id, name, type, server_id, slow_mode_seconds, description, topic, icon_url, created_at, updated_at;",
+		name,
+		serverID,
+	)
+	return scanChat(row)
+}
+
+// DeleteChannel deletes the channel together with its new message notifications.
+func (s *ServerService) DeleteChannel(serverID, chatID int) error {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("ServerService", "DeleteChannel", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return err
+	}
+	nmn := types.NewMessageNotification
+	_, err = tx.Exec(
+		"DELETE FROM notifications WHERE type = $1 AND (data->>'chatId')::int IN (SELECT id FROM chats WHERE id = $2 AND server_id = $3);",
+		nmn.String(),
+		chatID,
+		serverID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE FROM chats WHERE id = $1 AND server_id = $2;",
+		chatID,
+		serverID,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func NewServerService(db *Database) *ServerService {
+	return &ServerService{db: db}
+}
@@ -2,9 +2,13 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/types"
 	"github.com/kacperhemperek/discord-go/utils"
 	"strings"
 	"time"
@@ -16,14 +20,31 @@ type UserService struct {
 
 type UserServiceInterface interface {
 	FindUserByEmail(email string) (*models.User, error)
-	CreateUser(username, password, email string) (*models.User, error)
+	FindUserByHandle(handle string) (*models.User, error)
+	CreateUser(username, handle, password, email string) (*models.User, error)
 	GetUsersByIDs(userIDs []int) ([]*models.User, error)
+	GetUserByID(userID int) (*models.User, error)
+	UpdateProfile(userID int, update *models.ProfileUpdate) (*models.User, error)
+	VerifyEmail(userID int, email string) (*models.User, error)
+	GetSessionVersion(userID int) (int, error)
+	UpdatePassword(userID int, hashedPassword string) (int, error)
+	ChangeEmail(userID int, email string, sessionVersion int) (int, error)
+	SetAvatar(userID int, avatar *models.Avatar) (*models.User, error)
+	GetPrivacySettings(userID int) (*models.PrivacySettings, error)
+	UpdatePrivacySettings(userID int, settings *models.PrivacySettings) error
+	GetRelatedUserIDs(userID int) ([]int, error)
+	GetLastSeenAt(userID int) (models.NullTime, error)
+	GetCustomStatus(userID int) (*models.CustomStatus, error)
+	SetCustomStatus(userID int, status *models.CustomStatus) (*models.CustomStatus, error)
+	ClearExpiredCustomStatuses() ([]int, error)
+	OwnsChats(userID int) (bool, error)
+	DeleteUser(userID int) ([]int, error)
 }
 
 func (s *UserService) FindUserByEmail(email string) (*models.User, error) {
 	defer utils.LogServiceCall("UserService", "FindUserByEmail", time.Now())
 	rows, err := s.db.Query(
-		"SELECT id, username, email, active, password, created_at, updated_at FROM users WHERE email = $1;",
+		"SELECT id, username, handle, display_name, bio, avatar, banner_color, email, active, password, created_at, updated_at FROM users WHERE email = $1 AND NOT is_deleted_placeholder;",
 		email,
 	)
 
@@ -58,14 +79,31 @@ func (s *UserService) FindUserByEmail(email string) (*models.User, error) {
 	return nil, UserNotFoundError
 }
 
-func (s *UserService) CreateUser(username, password, email string) (*models.User, error) {
+// FindUserByHandle looks the user up by handle ignoring its case, handles are unique regardless of case.
+func (s *UserService) FindUserByHandle(handle string) (*models.User, error) {
+	defer utils.LogServiceCall("UserService", "FindUserByHandle", time.Now())
+	row := s.db.QueryRow(
+		"SELECT id, username, handle, display_name, bio, avatar, banner_color, email, active, password, created_at, updated_at FROM users WHERE LOWER(handle) = LOWER($1) AND NOT is_deleted_placeholder;",
+		handle,
+	)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, UserNotFoundError
+	}
+	return user, err
+}
+
+func (s *UserService) CreateUser(username, handle, password, email string) (*models.User, error) {
 	defer utils.LogServiceCall("UserService", "CreateUser", time.Now())
 	rows, err := s.db.Query(
-		"INSERT INTO users (username, password, email) VALUES ($1, $2, $3) RETURNING id, username, email, active, password, created_at, updated_at;",
-		username, password, email,
+		"INSERT INTO users (username, handle, password, email) VALUES ($1, $2, $3, $4) RETURNING id, username, handle, display_name, bio, avatar, banner_color, email, active, password, created_at, updated_at;",
+		username, handle, password, email,
 	)
 
 	if err != nil {
+		if isHandleTaken(err) {
+			return nil, HandleTakenError
+		}
 		return nil, err
 	}
 
@@ -86,6 +124,11 @@ func (s *UserService) CreateUser(username, password, email string) (*models.User
 		return user, nil
 	}
 
+	// the unique violation can also be reported only once the rows are read
+	if isHandleTaken(rows.Err()) {
+		return nil, HandleTakenError
+	}
+
 	return nil, UserUnknownError
 }
 
@@ -95,7 +138,7 @@ func (s *UserService) GetUsersByIDs(userIDs []int) ([]*models.User, error) {
 		placeholders[i] = fmt.Sprintf("$%d", i+1)
 	}
 
-	query := fmt.Sprintf("SELECT id, username, email, active, password, created_at, updated_at FROM users WHERE id IN (%s)", strings.Join(placeholders, ", "))
+	query := fmt.Sprintf("SELECT id, username, handle, display_name, bio, avatar, banner_color, email, active, password, created_at, updated_at FROM users WHERE id IN (%s) AND NOT is_deleted_placeholder", strings.Join(placeholders, ", "))
 
 	params := make([]any, len(userIDs))
 	for i, id := range userIDs {
@@ -119,10 +162,404 @@ func (s *UserService) GetUsersByIDs(userIDs []int) ([]*models.User, error) {
 	return users, nil
 }
 
+// GetUserByID never returns the "Deleted User" placeholder, like the other lookups it's hidden from users.
+func (s *UserService) GetUserByID(userID int) (*models.User, error) {
+	defer utils.LogServiceCall("UserService", "GetUserByID", time.Now())
+	row := s.db.QueryRow(
+		"SELECT id, username, handle, display_name, bio, avatar, banner_color, email, active, password, created_at, updated_at FROM users WHERE id = $1 AND NOT is_deleted_placeholder;",
+		userID,
+	)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, UserNotFoundError
+	}
+	return user, err
+}
+
+// VerifyEmail activates the user as long as the verified email is still their current one.
+func (s *UserService) VerifyEmail(userID int, email string) (*models.User, error) {
+	defer utils.LogServiceCall("UserService", "VerifyEmail", time.Now())
+	row := s.db.QueryRow(
+		"UPDATE users SET active = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND email = $2 RETURNING id, username, handle, display_name, bio, avatar, banner_color, email, active, password, created_at, updated_at;",
+		userID,
+		email,
+	)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, UserNotFoundError
+	}
+	return user, err
+}
+
+// GetSessionVersion returns the version every refresh token of the user has to carry.
+func (s *UserService) GetSessionVersion(userID int) (int, error) {
+	defer utils.LogServiceCall("UserService", "GetSessionVersion", time.Now())
+	var version int
+	err := s.db.QueryRow("SELECT session_version FROM users WHERE id = $1;", userID).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, UserNotFoundError
+	}
+	return version, err
+}
+
+// UpdatePassword replaces the password hash and revokes all of the user's sessions,
+// it returns the new session version.
+func (s *UserService) UpdatePassword(userID int, hashedPassword string) (int, error) {
+	defer utils.LogServiceCall("UserService", "UpdatePassword", time.Now())
+	var version int
+	err := s.db.QueryRow(
+		"UPDATE users SET password = $1, session_version = session_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING session_version;",
+		hashedPassword,
+		userID,
+	).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, UserNotFoundError
+	}
+	return version, err
+}
+
+// ChangeEmail switches the user to an already verified email and revokes all of their sessions, it returns
+// the new session version. The change is only made while the user is still at sessionVersion, so the same
+// confirmation can't be used twice.
+func (s *UserService) ChangeEmail(userID int, email string, sessionVersion int) (int, error) {
+	defer utils.LogServiceCall("UserService", "ChangeEmail", time.Now())
+	var version int
+	err := s.db.QueryRow(
+		"UPDATE users SET email = $1, active = TRUE, session_version = session_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND session_version = $3 RETURNING session_version;",
+		email,
+		userID,
+		sessionVersion,
+	).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, SessionVersionChangedError
+	}
+	if isEmailTaken(err) {
+		return -1, EmailTakenError
+	}
+	return version, err
+}
+
+// UpdateProfile changes only the fields set in the update and returns the updated user.
+func (s *UserService) UpdateProfile(userID int, update *models.ProfileUpdate) (*models.User, error) {
+	defer utils.LogServiceCall("UserService", "UpdateProfile", time.Now())
+	sets := []string{"updated_at = CURRENT_TIMESTAMP"}
+	args := pgx.NamedArgs{"user_id": userID}
+	if update.DisplayName != nil {
+		sets = append(sets, "display_name = NULLIF(@display_name, '')")
+		args["display_name"] = *update.DisplayName
+	}
+	if update.Bio != nil {
+		sets = append(sets, "bio = NULLIF(@bio, '')")
+		args["bio"] = *update.Bio
+	}
+	if update.BannerColor != nil {
+		sets = append(sets, "banner_color = NULLIF(@banner_color, '')")
+		args["banner_color"] = *update.BannerColor
+	}
+	row := s.db.QueryRow(
+		"UPDATE users SET "+strings.Join(sets, ", ")+" WHERE id = @user_id RETURNING id, username, handle, display_name, bio, avatar, banner_color, email, active, password, created_at, updated_at;",
+		args,
+	)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, UserNotFoundError
+	}
+	return user, err
+}
+
+// SetAvatar replaces the user's avatar, passing nil removes it.
+func (s *UserService) SetAvatar(userID int, avatar *models.Avatar) (*models.User, error) {
+	defer utils.LogServiceCall("UserService", "SetAvatar", time.Now())
+	var avatarJSON []byte
+	if avatar != nil {
+		var err error
+		if avatarJSON, err = json.Marshal(avatar); err != nil {
+			return nil, err
+		}
+	}
+	row := s.db.QueryRow(
+		"UPDATE users SET avatar = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING id, username, handle, display_name, bio, avatar, banner_color, email, active, password, created_at, updated_at;",
+		avatarJSON,
+		userID,
+	)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, UserNotFoundError
+	}
+	return user, err
+}
+
+func (s *UserService) GetPrivacySettings(userID int) (*models.PrivacySettings, error) {
+	defer utils.LogServiceCall("UserService", "GetPrivacySettings", time.Now())
+	settings := &models.PrivacySettings{}
+	err := s.db.QueryRow(
+		"SELECT friend_request_privacy, show_last_seen FROM users WHERE id = $1;",
+		userID,
+	).Scan(&settings.FriendRequests, &settings.ShowLastSeen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, UserNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *UserService) UpdatePrivacySettings(userID int, settings *models.PrivacySettings) error {
+	defer utils.LogServiceCall("UserService", "UpdatePrivacySettings", time.Now())
+	_, err := s.db.Exec(
+		"UPDATE users SET friend_request_privacy = $1, show_last_seen = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3;",
+		settings.FriendRequests.String(),
+		settings.ShowLastSeen,
+		userID,
+	)
+	return err
+}
+
+// GetLastSeenAt returns when the user was last active, the time is not valid when they
+// have never been seen or hide it in their privacy settings.
+func (s *UserService) GetLastSeenAt(userID int) (models.NullTime, error) {
+	defer utils.LogServiceCall("UserService", "GetLastSeenAt", time.Now())
+	lastSeenAt := models.NullTime{}
+	err := s.db.QueryRow(
+		"SELECT CASE WHEN show_last_seen THEN last_seen_at END FROM users WHERE id = $1;",
+		userID,
+	).Scan(&lastSeenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return lastSeenAt, UserNotFoundError
+	}
+	return lastSeenAt, err
+}
+
+// GetRelatedUserIDs returns ids of the user's friends and of everyone they share a chat with,
+// users blocked in either direction are left out.
+func (s *UserService) GetRelatedUserIDs(userID int) ([]int, error) {
+	defer utils.LogServiceCall("UserService", "GetRelatedUserIDs", time.Now())
+	rows, err := s.db.Query(
+		fmt.Sprintf(`
+		SELECT id FROM (%s) friends
+		UNION
+		SELECT other.user_id FROM chat_to_user me
+		    JOIN chat_to_user other ON other.chat_id = me.chat_id AND other.user_id <> $1
+		    WHERE me.user_id = $1
+		EXCEPT
+		SELECT blocked_id FROM user_blocks WHERE blocker_id = $1
+		EXCEPT
+		SELECT blocker_id FROM user_blocks WHERE blocked_id = $1;`,
+			fmt.Sprintf(acceptedFriendIDsSQL, "$1"),
+		),
+		userID,
+	)
+	ids := make([]int, 0)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		id, err := scanID(rows)
+		if err != nil {
+			return make([]int, 0), err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetCustomStatus returns the user's custom status or nil when they have none or it expired.
+func (s *UserService) GetCustomStatus(userID int) (*models.CustomStatus, error) {
+	defer utils.LogServiceCall("UserService", "GetCustomStatus", time.Now())
+	row := s.db.QueryRow(
+		"SELECT text, emoji, expires_at, do_not_disturb FROM user_custom_statuses WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > $2);",
+		userID,
+		time.Now().UTC(),
+	)
+	status, err := scanCustomStatus(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return status, err
+}
+
+// SetCustomStatus replaces the user's custom status, passing nil clears it.
+func (s *UserService) SetCustomStatus(userID int, status *models.CustomStatus) (*models.CustomStatus, error) {
+	defer utils.LogServiceCall("UserService", "SetCustomStatus", time.Now())
+	if status == nil {
+		_, err := s.db.Exec("DELETE FROM user_custom_statuses WHERE user_id = $1;", userID)
+		return nil, err
+	}
+	var expiresAt *time.Time
+	if status.ExpiresAt.Valid {
+		expiresAt = &status.ExpiresAt.Time
+	}
+	row := s.db.QueryRow(`
+		INSERT INTO user_custom_statuses (user_id, text, emoji, expires_at, do_not_disturb) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+			SET text = EXCLUDED.text,
+			    emoji = EXCLUDED.emoji,
+			    expires_at = EXCLUDED.expires_at,
+			    do_not_disturb = EXCLUDED.do_not_disturb,
+			    updated_at = CURRENT_TIMESTAMP
+		RETURNING text, emoji, expires_at, do_not_disturb;`,
+		userID,
+		status.Text,
+		status.Emoji,
+		expiresAt,
+		status.DoNotDisturb,
+	)
+	return scanCustomStatus(row)
+}
+
+// ClearExpiredCustomStatuses deletes statuses past their expiry and returns ids of their users.
+func (s *UserService) ClearExpiredCustomStatuses() ([]int, error) {
+	defer utils.LogServiceCall("UserService", "ClearExpiredCustomStatuses", time.Now())
+	rows, err := s.db.Query(
+		"DELETE FROM user_custom_statuses WHERE expires_at <= $1 RETURNING user_id;",
+		time.Now().UTC(),
+	)
+	ids := make([]int, 0)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		id, err := scanID(rows)
+		if err != nil {
+			return make([]int, 0), err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ownedChatsSQL counts the group chats and servers owned by the user.
+const ownedChatsSQL = `
+	SELECT (SELECT COUNT(*) FROM chat_to_user cu JOIN chats c ON c.id = cu.chat_id WHERE cu.user_id = $1 AND cu.role = $2 AND c.type = $3)
+		+ (SELECT COUNT(*) FROM servers WHERE owner_id = $1);`
+
+// OwnsChats reports whether the user owns a group chat or a server, those have to be handed over
+// before the account can be deleted.
+func (s *UserService) OwnsChats(userID int) (bool, error) {
+	defer utils.LogServiceCall("UserService", "OwnsChats", time.Now())
+	owner := types.OwnerChatRole
+	group := types.GroupChat
+	return ownsChats(s.db.QueryRow(ownedChatsSQL, userID, owner.String(), group.String()))
+}
+
+// DeleteUser removes the account together with its friendships, notifications and private chats,
+// messages sent to group chats are kept and re-attributed to the "Deleted User" account.
+// It returns ids of the removed private chats.
+func (s *UserService) DeleteUser(userID int) ([]int, error) {
+	tx, err := s.db.Begin()
+	defer func(now time.Time) {
+		utils.LogServiceCall("UserService", "DeleteUser", now)
+		rollback(tx)
+	}(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	owner := types.OwnerChatRole
+	group := types.GroupChat
+	private := types.PrivateChat
+	owns, err := ownsChats(tx.QueryRow(ownedChatsSQL, userID, owner.String(), group.String()))
+	if err != nil {
+		return nil, err
+	}
+	if owns {
+		return nil, UserOwnsChatsError
+	}
+	nmn := types.NewMessageNotification
+	_, err = tx.Exec(
+		`DELETE FROM notifications WHERE type = $1 AND (data->>'chatId')::int IN (
+			SELECT c.id FROM chats c JOIN chat_to_user cu ON cu.chat_id = c.id WHERE cu.user_id = $2 AND c.type = $3
+		);`,
+		nmn.String(),
+		userID,
+		private.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(
+		"DELETE FROM chats WHERE type = $1 AND id IN (SELECT chat_id FROM chat_to_user WHERE user_id = $2) RETURNING id;",
+		private.String(),
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	chatIDs := make([]int, 0)
+	for rows.Next() {
+		id, err := scanID(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		chatIDs = append(chatIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(
+		`DELETE FROM notifications WHERE user_id = $1 OR (data->>'friendshipId')::int IN (
+			SELECT id FROM friendships WHERE inviter_id = $1 OR friend_id = $1
+		);`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("DELETE FROM friendships WHERE inviter_id = $1 OR friend_id = $1;", userID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(
+		"UPDATE messages SET sender_id = (SELECT id FROM users WHERE is_deleted_placeholder) WHERE sender_id = $1;",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.Exec("DELETE FROM users WHERE id = $1 AND NOT is_deleted_placeholder;", userID)
+	if err != nil {
+		return nil, err
+	}
+	if deleted, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if deleted == 0 {
+		return nil, UserNotFoundError
+	}
+	return chatIDs, tx.Commit()
+}
+
+func ownsChats(row *sql.Row) (bool, error) {
+	var count int
+	err := row.Scan(&count)
+	return count > 0, err
+}
+
+func isHandleTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "user_handle_index"
+}
+
+func isEmailTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "user_email_index"
+}
+
 var UserNotFoundError = errors.New("user not found")
 
 var UserUnknownError = errors.New("unknown error")
 
+var HandleTakenError = errors.New("handle already taken")
+
+var EmailTakenError = errors.New("email already taken")
+
+var UserOwnsChatsError = errors.New("user owns group chats or servers")
+
+var SessionVersionChangedError = errors.New("session version changed")
+
 func NewUserService(db *Database) *UserService {
 	return &UserService{db: db}
 }
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+type ChatAuditAction int64
+
+var (
+	InvalidChatAuditActionErr = errors.New("invalid chat audit action")
+)
+
+const (
+	ChatUpdatedAuditAction ChatAuditAction = iota
+	MembersAddedAuditAction
+	MemberKickedAuditAction
+	MemberRoleUpdatedAuditAction
+	SlowModeUpdatedAuditAction
+	OwnershipTransferredAuditAction
+	InviteRevokedAuditAction
+)
+
+var chatAuditActionNames = map[ChatAuditAction]string{
+	ChatUpdatedAuditAction:          "chat_updated",
+	MembersAddedAuditAction:         "members_added",
+	MemberKickedAuditAction:         "member_kicked",
+	MemberRoleUpdatedAuditAction:    "member_role_updated",
+	SlowModeUpdatedAuditAction:      "slow_mode_updated",
+	OwnershipTransferredAuditAction: "ownership_transferred",
+	InviteRevokedAuditAction:        "invite_revoked",
+}
+
+func (n *ChatAuditAction) String() string {
+	return chatAuditActionNames[*n]
+}
+
+// ParseChatAuditAction returns the action with the given name, used for filtering the audit log.
+func ParseChatAuditAction(value string) (ChatAuditAction, error) {
+	for action, name := range chatAuditActionNames {
+		if name == value {
+			return action, nil
+		}
+	}
+	return 0, InvalidChatAuditActionErr
+}
+
+func (n *ChatAuditAction) UnmarshalJSON(data []byte) error {
+	var dataStr string
+	if err := json.Unmarshal(data, &dataStr); err != nil {
+		return InvalidChatAuditActionErr
+	}
+	action, err := ParseChatAuditAction(dataStr)
+	if err != nil {
+		return err
+	}
+	*n = action
+	return nil
+}
+
+func (n *ChatAuditAction) MarshalJSON() ([]byte, error) {
+	str := n.String()
+	if str == "" {
+		return []byte(""), InvalidChatAuditActionErr
+	}
+	return json.Marshal(str)
+}
+
+func (n *ChatAuditAction) Scan(value any) error {
+	switch v := value.(type) {
+	case string:
+		action, err := ParseChatAuditAction(v)
+		if err != nil {
+			return err
+		}
+		*n = action
+		return nil
+	default:
+		return InvalidChatAuditActionErr
+	}
+}
+
+func (n *ChatAuditAction) Is(comp ChatAuditAction) bool {
+	return n.String() == comp.String()
+}
@@ -0,0 +1,116 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+type ChatRole int64
+
+var (
+	InvalidChatRoleErr = errors.New("invalid chat role")
+)
+
+const (
+	MemberChatRole ChatRole = iota
+	AdminChatRole
+	OwnerChatRole
+	ModeratorChatRole
+)
+
+func (n *ChatRole) String() string {
+	switch *n {
+	case MemberChatRole:
+		return "member"
+	case AdminChatRole:
+		return "admin"
+	case OwnerChatRole:
+		return "owner"
+	case ModeratorChatRole:
+		return "moderator"
+	default:
+		return ""
+	}
+}
+
+func (n *ChatRole) UnmarshalJSON(data []byte) error {
+	var dataStr string
+	if err := json.Unmarshal(data, &dataStr); err != nil {
+		return InvalidChatRoleErr
+	}
+
+	switch dataStr {
+	case "member":
+		*n = MemberChatRole
+	case "admin":
+		*n = AdminChatRole
+	case "owner":
+		*n = OwnerChatRole
+	case "moderator":
+		*n = ModeratorChatRole
+	default:
+		return InvalidChatRoleErr
+	}
+	return nil
+}
+
+func (n *ChatRole) MarshalJSON() ([]byte, error) {
+	str := n.String()
+	if str == "" {
+		return []byte(""), InvalidChatRoleErr
+	}
+	return json.Marshal(str)
+}
+
+func (n *ChatRole) Scan(value any) error {
+	switch v := value.(type) {
+	case string:
+		switch v {
+		case "member":
+			*n = MemberChatRole
+		case "admin":
+			*n = AdminChatRole
+		case "owner":
+			*n = OwnerChatRole
+		case "moderator":
+			*n = ModeratorChatRole
+		default:
+			return InvalidChatRoleErr
+		}
+		return nil
+	default:
+		return InvalidChatRoleErr
+	}
+}
+
+func (n *ChatRole) Is(comp ChatRole) bool {
+	return n.String() == comp.String()
+}
+
+// IsAdmin reports whether the role is allowed to manage the chat, owners are always admins.
+func (n *ChatRole) IsAdmin() bool {
+	return n.Is(AdminChatRole) || n.Is(OwnerChatRole)
+}
+
+// IsModerator reports whether the role is allowed to moderate chat members, every admin is a moderator too.
+func (n *ChatRole) IsModerator() bool {
+	return n.Is(ModeratorChatRole) || n.IsAdmin()
+}
+
+// Outranks reports whether the role is higher than the other one, members can only be moderated by someone who outranks them.
+func (n *ChatRole) Outranks(other ChatRole) bool {
+	return n.rank() > other.rank()
+}
+
+func (n *ChatRole) rank() int {
+	switch *n {
+	case ModeratorChatRole:
+		return 1
+	case AdminChatRole:
+		return 2
+	case OwnerChatRole:
+		return 3
+	default:
+		return 0
+	}
+}
@@ -14,6 +14,7 @@ var (
 const (
 	PrivateChat ChatType = iota
 	GroupChat
+	ChannelChat
 )
 
 func (n *ChatType) String() string {
@@ -22,6 +23,8 @@ func (n *ChatType) String() string {
 		return "private"
 	case GroupChat:
 		return "group"
+	case ChannelChat:
+		return "channel"
 	default:
 		return ""
 	}
@@ -37,6 +40,9 @@ func (n *ChatType) UnmarshalJSON(data []byte) error {
 	case "group":
 		*n = GroupChat
 		break
+	case "channel":
+		*n = ChannelChat
+		break
 	default:
 		return InvalidChatTypeErr
 	}
@@ -49,6 +55,8 @@ func (n *ChatType) MarshalJSON() ([]byte, error) {
 		return json.Marshal("private")
 	case GroupChat:
 		return json.Marshal("group")
+	case ChannelChat:
+		return json.Marshal("channel")
 	default:
 		return []byte(""), InvalidChatTypeErr
 	}
@@ -67,6 +75,11 @@ func (n *ChatType) Scan(value any) error {
 				*n = GroupChat
 				return nil
 			}
+
+			if value == "channel" {
+				*n = ChannelChat
+				return nil
+			}
 			return InvalidChatTypeErr
 		}
 	default:
@@ -0,0 +1,82 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// DataExportStatus tells whether the user's data export can be downloaded yet.
+type DataExportStatus int64
+
+var (
+	InvalidDataExportStatusErr = errors.New("invalid data export status")
+)
+
+const (
+	PendingDataExport DataExportStatus = iota
+	ReadyDataExport
+	FailedDataExport
+)
+
+func (n *DataExportStatus) String() string {
+	switch *n {
+	case PendingDataExport:
+		return "pending"
+	case ReadyDataExport:
+		return "ready"
+	case FailedDataExport:
+		return "failed"
+	default:
+		return ""
+	}
+}
+
+func (n *DataExportStatus) UnmarshalJSON(data []byte) error {
+	var dataStr string
+	if err := json.Unmarshal(data, &dataStr); err != nil {
+		return InvalidDataExportStatusErr
+	}
+
+	switch dataStr {
+	case "pending":
+		*n = PendingDataExport
+	case "ready":
+		*n = ReadyDataExport
+	case "failed":
+		*n = FailedDataExport
+	default:
+		return InvalidDataExportStatusErr
+	}
+	return nil
+}
+
+func (n *DataExportStatus) MarshalJSON() ([]byte, error) {
+	str := n.String()
+	if str == "" {
+		return []byte(""), InvalidDataExportStatusErr
+	}
+	return json.Marshal(str)
+}
+
+func (n *DataExportStatus) Scan(value any) error {
+	switch v := value.(type) {
+	case string:
+		switch v {
+		case "pending":
+			*n = PendingDataExport
+		case "ready":
+			*n = ReadyDataExport
+		case "failed":
+			*n = FailedDataExport
+		default:
+			return InvalidDataExportStatusErr
+		}
+		return nil
+	default:
+		return InvalidDataExportStatusErr
+	}
+}
+
+func (n *DataExportStatus) Is(comp DataExportStatus) bool {
+	return n.String() == comp.String()
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// FriendRequestPrivacy is the user's setting for who is allowed to send them friend requests.
+type FriendRequestPrivacy int64
+
+var (
+	InvalidFriendRequestPrivacyErr = errors.New("invalid friend request privacy")
+)
+
+const (
+	EveryoneFriendRequestPrivacy FriendRequestPrivacy = iota
+	FriendsOfFriendsFriendRequestPrivacy
+	NobodyFriendRequestPrivacy
+)
+
+func (n *FriendRequestPrivacy) String() string {
+	switch *n {
+	case EveryoneFriendRequestPrivacy:
+		return "everyone"
+	case FriendsOfFriendsFriendRequestPrivacy:
+		return "friends_of_friends"
+	case NobodyFriendRequestPrivacy:
+		return "nobody"
+	default:
+		return ""
+	}
+}
+
+func (n *FriendRequestPrivacy) UnmarshalJSON(data []byte) error {
+	var dataStr string
+	if err := json.Unmarshal(data, &dataStr); err != nil {
+		return InvalidFriendRequestPrivacyErr
+	}
+	return n.parse(dataStr)
+}
+
+func (n *FriendRequestPrivacy) MarshalJSON() ([]byte, error) {
+	str := n.String()
+	if str == "" {
+		return []byte(""), InvalidFriendRequestPrivacyErr
+	}
+	return json.Marshal(str)
+}
+
+func (n *FriendRequestPrivacy) Scan(value any) error {
+	switch v := value.(type) {
+	case string:
+		return n.parse(v)
+	default:
+		return InvalidFriendRequestPrivacyErr
+	}
+}
+
+func (n *FriendRequestPrivacy) Is(comp FriendRequestPrivacy) bool {
+	return n.String() == comp.String()
+}
+
+func (n *FriendRequestPrivacy) parse(value string) error {
+	switch value {
+	case "everyone":
+		*n = EveryoneFriendRequestPrivacy
+	case "friends_of_friends":
+		*n = FriendsOfFriendsFriendRequestPrivacy
+	case "nobody":
+		*n = NobodyFriendRequestPrivacy
+	default:
+		return InvalidFriendRequestPrivacyErr
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+type MessageKind int64
+
+var (
+	InvalidMessageKindErr = errors.New("invalid message kind")
+)
+
+const (
+	UserMessage MessageKind = iota
+	SystemMessage
+)
+
+func (n *MessageKind) String() string {
+	switch *n {
+	case UserMessage:
+		return "user"
+	case SystemMessage:
+		return "system"
+	default:
+		return ""
+	}
+}
+
+func (n *MessageKind) UnmarshalJSON(data []byte) error {
+	var dataStr string
+	if err := json.Unmarshal(data, &dataStr); err != nil {
+		return InvalidMessageKindErr
+	}
+
+	switch dataStr {
+	case "user":
+		*n = UserMessage
+	case "system":
+		*n = SystemMessage
+	default:
+		return InvalidMessageKindErr
+	}
+	return nil
+}
+
+func (n *MessageKind) MarshalJSON() ([]byte, error) {
+	str := n.String()
+	if str == "" {
+		return []byte(""), InvalidMessageKindErr
+	}
+	return json.Marshal(str)
+}
+
+func (n *MessageKind) Scan(value any) error {
+	switch v := value.(type) {
+	case string:
+		switch v {
+		case "user":
+			*n = UserMessage
+		case "system":
+			*n = SystemMessage
+		default:
+			return InvalidMessageKindErr
+		}
+		return nil
+	default:
+		return InvalidMessageKindErr
+	}
+}
+
+func (n *MessageKind) Is(comp MessageKind) bool {
+	return n.String() == comp.String()
+}
+
+// system message payload types, they describe what happened in the chat
+const (
+	ChatCreatedSystemMessage   = "chat_created"
+	ChatRenamedSystemMessage   = "chat_renamed"
+	MemberAddedSystemMessage   = "member_added"
+	MemberRemovedSystemMessage = "member_removed"
+	MemberLeftSystemMessage    = "member_left"
+)
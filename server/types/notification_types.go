@@ -14,6 +14,7 @@ var (
 const (
 	FriendRequestNotification NotificationType = iota
 	NewMessageNotification
+	FriendRequestAcceptedNotification
 )
 
 func (n *NotificationType) String() string {
@@ -22,6 +23,8 @@ func (n *NotificationType) String() string {
 		return "friend_request"
 	case NewMessageNotification:
 		return "new_message"
+	case FriendRequestAcceptedNotification:
+		return "friend_request_accepted"
 	default:
 		return "unsupported_notification_type"
 	}
@@ -37,6 +40,9 @@ func (n *NotificationType) UnmarshalJSON(data []byte) error {
 	case "new_message":
 		*n = NewMessageNotification
 		return nil
+	case "friend_request_accepted":
+		*n = FriendRequestAcceptedNotification
+		return nil
 	default:
 		return InvalidNotificationTypeErr
 	}
@@ -48,6 +54,8 @@ func (n *NotificationType) MarshalJSON() ([]byte, error) {
 		return json.Marshal("friend_request")
 	case NewMessageNotification:
 		return json.Marshal("new_message")
+	case FriendRequestAcceptedNotification:
+		return json.Marshal("friend_request_accepted")
 	default:
 		return []byte(""), errors.New("invalid notification type")
 	}
@@ -67,6 +75,11 @@ func (n *NotificationType) Scan(value any) error {
 				return nil
 			}
 
+			if value == "friend_request_accepted" {
+				*n = FriendRequestAcceptedNotification
+				return nil
+			}
+
 			return InvalidNotificationTypeErr
 		}
 	default:
@@ -77,5 +90,6 @@ func (n *NotificationType) Scan(value any) error {
 func IsNotificationType(value string) bool {
 	newMessage := NewMessageNotification
 	friendRequest := FriendRequestNotification
-	return value == newMessage.String() || value == friendRequest.String()
+	friendRequestAccepted := FriendRequestAcceptedNotification
+	return value == newMessage.String() || value == friendRequest.String() || value == friendRequestAccepted.String()
 }
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+type PresenceStatus int64
+
+var (
+	InvalidPresenceStatusErr = errors.New("invalid presence status")
+)
+
+const (
+	OfflinePresenceStatus PresenceStatus = iota
+	OnlinePresenceStatus
+	IdlePresenceStatus
+)
+
+func (n *PresenceStatus) String() string {
+	switch *n {
+	case OfflinePresenceStatus:
+		return "offline"
+	case OnlinePresenceStatus:
+		return "online"
+	case IdlePresenceStatus:
+		return "idle"
+	default:
+		return ""
+	}
+}
+
+func (n *PresenceStatus) UnmarshalJSON(data []byte) error {
+	var dataStr string
+	if err := json.Unmarshal(data, &dataStr); err != nil {
+		return InvalidPresenceStatusErr
+	}
+
+	switch dataStr {
+	case "offline":
+		*n = OfflinePresenceStatus
+	case "online":
+		*n = OnlinePresenceStatus
+	case "idle":
+		*n = IdlePresenceStatus
+	default:
+		return InvalidPresenceStatusErr
+	}
+	return nil
+}
+
+func (n *PresenceStatus) MarshalJSON() ([]byte, error) {
+	str := n.String()
+	if str == "" {
+		return []byte(""), InvalidPresenceStatusErr
+	}
+	return json.Marshal(str)
+}
+
+func (n *PresenceStatus) Is(comp PresenceStatus) bool {
+	return n.String() == comp.String()
+}
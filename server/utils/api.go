@@ -7,9 +7,12 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"math"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -29,11 +32,18 @@ func WsHandler(handler APIHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		defer logRequest(r, time.Now())
 
-		handlerErr := handler(w, r, &APIContext{})
+		c := &APIContext{}
+		handlerErr := handler(w, r, c)
 		if handlerErr != nil {
 			var err *APIError
 			if errors.As(handlerErr, &err) {
 				logApiError(err, r)
+				// errors returned after the upgrade can't be sent as a http response,
+				// so they are sent through the socket which is closed afterwards
+				if c.Conn != nil {
+					_ = c.Conn.WriteJSON(err)
+					_ = c.Conn.Close()
+				}
 				return
 			}
 			logError(handlerErr, r)
@@ -95,6 +105,17 @@ func WriteJson(w http.ResponseWriter, status int, data interface{}) error {
 	return nil
 }
 
+// WriteTooManyRequests responds with 429 and tells the client after how many seconds it can retry.
+func WriteTooManyRequests(w http.ResponseWriter, message string, retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	return WriteJson(w, http.StatusTooManyRequests, &JSON{
+		"code":       http.StatusTooManyRequests,
+		"message":    message,
+		"retryAfter": seconds,
+	})
+}
+
 func GetIntParam(r *http.Request, param string) (int, error) {
 	params := mux.Vars(r)
 	value, ok := params[param]
@@ -109,6 +130,16 @@ func GetIntParam(r *http.Request, param string) (int, error) {
 	return number, nil
 }
 
+func GetStringParam(r *http.Request, param string) (string, error) {
+	params := mux.Vars(r)
+	value, ok := params[param]
+	if !ok || value == "" {
+		return "", errors.New("missing param")
+	}
+
+	return value, nil
+}
+
 type APIHandler func(w http.ResponseWriter, r *http.Request, c *APIContext) error
 
 type APIContext struct {
@@ -117,3 +148,12 @@ type APIContext struct {
 }
 
 type JSON map[string]interface{}
+
+// ClientURL returns the url of the path in the web client, the client is expected at CLIENT_URL.
+func ClientURL(path string) string {
+	base := os.Getenv("CLIENT_URL")
+	if base == "" {
+		base = "http://localhost:5173"
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
 	"time"
@@ -20,6 +24,22 @@ func EncryptPassword(password string) (string, error) {
 	return string(hashedPassword), nil
 }
 
+// NewRandomToken returns a url safe token with 256 bits of randomness.
+func NewRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hash of a random token that can be stored instead of the token itself,
+// a fast hash is enough since the token can't be guessed.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
 func GetAccessToken(r *http.Request) (string, error) {
 	cookie, err := r.Cookie(AccessTokenCookieName)
 	if err != nil {
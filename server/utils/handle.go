@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"github.com/go-playground/validator/v10"
+	"regexp"
+	"strings"
+)
+
+var handleRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.]{3,32}$`)
+
+// ValidateHandle is registered as the "handle" validation tag. Handles are 3 to 32 letters,
+// digits, underscores or dots so they can be shared and typed without surprises.
+func ValidateHandle(fl validator.FieldLevel) bool {
+	return IsValidHandle(fl.Field().String())
+}
+
+func IsValidHandle(handle string) bool {
+	return handleRegexp.MatchString(handle)
+}
+
+// NormalizeHandle strips the @ users tend to type in front of a handle.
+func NormalizeHandle(handle string) string {
+	return strings.TrimPrefix(strings.TrimSpace(handle), "@")
+}
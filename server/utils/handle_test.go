@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestIsValidHandle(t *testing.T) {
+	cases := map[string]bool{
+		"john_doe":                          true,
+		"John.Doe99":                        true,
+		"ab":                                false,
+		"has space":                         false,
+		"emoji😀":                            false,
+		"abcdefghijklmnopqrstuvwxyz0123456": false,
+	}
+	for handle, want := range cases {
+		if got := IsValidHandle(handle); got != want {
+			t.Errorf("IsValidHandle(%q) = %v, want %v", handle, got, want)
+		}
+	}
+}
+
+func TestNormalizeHandle(t *testing.T) {
+	if got := NormalizeHandle(" @john_doe "); got != "john_doe" {
+		t.Errorf("expected handle without @ and spaces, got %q", got)
+	}
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+)
+
+// ResizeSquare crops the center square of the image and scales it to size x size. When scaling down
+// each target pixel is the average of the source pixels it covers, smaller images are scaled up
+// by repeating pixels.
+func ResizeSquare(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	left := bounds.Min.X + (bounds.Dx()-side)/2
+	top := bounds.Min.Y + (bounds.Dy()-side)/2
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := sourceSpan(top, side, size, y)
+		for x := 0; x < size; x++ {
+			x0, x1 := sourceSpan(left, side, size, x)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// sourceSpan returns the range of source coordinates covered by the target coordinate i,
+// the range always holds at least one pixel.
+func sourceSpan(start, side, size, i int) (int, int) {
+	from := start + i*side/size
+	to := start + (i+1)*side/size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
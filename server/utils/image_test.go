@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestResizeSquare_CropsCenterAndAverages(t *testing.T) {
+	// 6x4 image, the center 4x4 square has a white left half and a black right half,
+	// the cropped out columns are red
+	src := image.NewRGBA(image.Rect(0, 0, 6, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 6; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 1 && x < 3 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			} else if x >= 3 && x < 5 {
+				c = color.RGBA{A: 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	dst := ResizeSquare(src, 2)
+
+	if dst.Bounds().Dx() != 2 || dst.Bounds().Dy() != 2 {
+		t.Fatalf("Expected 2x2 image, got %v", dst.Bounds())
+	}
+	if c := dst.RGBAAt(0, 0); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("Expected left pixel to be white, got %v", c)
+	}
+	if c := dst.RGBAAt(1, 1); c != (color.RGBA{A: 255}) {
+		t.Errorf("Expected right pixel to be black, got %v", c)
+	}
+}
+
+func TestResizeSquare_MixesCoveredPixels(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.SetRGBA(0, 0, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	src.SetRGBA(1, 0, color.RGBA{A: 255})
+	src.SetRGBA(0, 1, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	src.SetRGBA(1, 1, color.RGBA{A: 255})
+
+	c := ResizeSquare(src, 1).RGBAAt(0, 0)
+
+	if c.R != 127 || c.A != 255 {
+		t.Errorf("Expected gray pixel, got %v", c)
+	}
+}
+
+func TestResizeSquare_ScalesUp(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	src.SetRGBA(0, 0, color.RGBA{G: 255, A: 255})
+
+	dst := ResizeSquare(src, 3)
+
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			if c := dst.RGBAAt(x, y); c != (color.RGBA{G: 255, A: 255}) {
+				t.Errorf("Expected pixel %d,%d to be green, got %v", x, y, c)
+			}
+		}
+	}
+}
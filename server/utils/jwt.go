@@ -19,6 +19,8 @@ type JWTUser struct {
 	Email     string    `json:"email"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	// SessionVersion is bumped to revoke the user's sessions, refresh tokens with an older one are rejected.
+	SessionVersion int `json:"sessionVersion"`
 	jwt.RegisteredClaims
 }
 
@@ -54,20 +56,22 @@ func SetAuthCookies(w http.ResponseWriter, accessToken string, refreshToken stri
 }
 
 type NewTokenProps struct {
-	ID        int
-	Email     string
-	Username  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID             int
+	Email          string
+	Username       string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	SessionVersion int
 }
 
 func NewAccessToken(u *NewTokenProps) (string, error) {
 	user := &JWTUser{
-		ID:        u.ID,
-		Username:  u.Username,
-		Email:     u.Email,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:             u.ID,
+		Username:       u.Username,
+		Email:          u.Email,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+		SessionVersion: u.SessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: getAccessTokenExpiryDate(),
 		},
@@ -85,11 +89,12 @@ func NewAccessToken(u *NewTokenProps) (string, error) {
 
 func NewRefreshToken(u *NewTokenProps) (string, error) {
 	user := &JWTUser{
-		ID:        u.ID,
-		Username:  u.Username,
-		Email:     u.Email,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:             u.ID,
+		Username:       u.Username,
+		Email:          u.Email,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+		SessionVersion: u.SessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: getRefreshTokenExpiryDate(),
 		},
@@ -131,6 +136,9 @@ func ParseUserToken(tokenString string) (*JWTUser, error) {
 			}
 			user.CreatedAt = date
 		}
+		if sessionVersion, ok := claims["sessionVersion"]; ok {
+			user.SessionVersion = int(sessionVersion.(float64))
+		}
 		if updatedAt, ok := claims["updatedAt"]; ok {
 			date, err := formatAnyToTime(updatedAt)
 			if err != nil {
@@ -191,3 +199,98 @@ func getAccessTokenExpiryDate() *jwt.NumericDate {
 func getRefreshTokenExpiryDate() *jwt.NumericDate {
 	return jwt.NewNumericDate(time.Now().Add(time.Hour * 24 * 7))
 }
+
+const (
+	emailVerificationTokenPurpose = "email-verification"
+	emailChangeTokenPurpose       = "email-change"
+)
+
+// EmailVerificationClaims prove that the user can read emails sent to Email.
+type EmailVerificationClaims struct {
+	UserID int    `json:"userId"`
+	Email  string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// NewEmailVerificationToken signs a token for confirming the email, it is signed with a key derived
+// for this purpose only so it can't be used as an access token or the other way around.
+func NewEmailVerificationToken(userID int, email string) (string, error) {
+	return newEmailToken(emailVerificationTokenPurpose, userID, email)
+}
+
+func ParseEmailVerificationToken(tokenString string) (*EmailVerificationClaims, error) {
+	return parseEmailToken(emailVerificationTokenPurpose, tokenString)
+}
+
+// EmailChangeClaims prove that the user can read emails sent to the new Email, the token is only valid
+// while the user's session version is SessionVersion so it stops working once the email is changed.
+type EmailChangeClaims struct {
+	SessionVersion int `json:"sessionVersion"`
+	EmailVerificationClaims
+}
+
+// NewEmailChangeToken signs a token for confirming the address the user wants to switch to,
+// it can't be used in place of a verification token of the current address.
+func NewEmailChangeToken(userID int, newEmail string, sessionVersion int) (string, error) {
+	claims := &EmailChangeClaims{
+		SessionVersion:          sessionVersion,
+		EmailVerificationClaims: *newEmailClaims(userID, newEmail),
+	}
+	return signPurposeToken(emailChangeTokenPurpose, claims)
+}
+
+func ParseEmailChangeToken(tokenString string) (*EmailChangeClaims, error) {
+	claims := &EmailChangeClaims{}
+	if err := parsePurposeToken(emailChangeTokenPurpose, tokenString, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func newEmailToken(purpose string, userID int, email string) (string, error) {
+	return signPurposeToken(purpose, newEmailClaims(userID, email))
+}
+
+func newEmailClaims(userID int, email string) *EmailVerificationClaims {
+	return &EmailVerificationClaims{
+		UserID: userID,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)),
+		},
+	}
+}
+
+func parseEmailToken(purpose string, tokenString string) (*EmailVerificationClaims, error) {
+	claims := &EmailVerificationClaims{}
+	if err := parsePurposeToken(purpose, tokenString, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func signPurposeToken(purpose string, claims jwt.Claims) (string, error) {
+	secret, err := getPurposeSecret(purpose)
+	if err != nil {
+		return "", err
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+func parsePurposeToken(purpose string, tokenString string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return getPurposeSecret(purpose)
+	}, jwt.WithExpirationRequired())
+	return err
+}
+
+func getPurposeSecret(purpose string) ([]byte, error) {
+	secret, err := getJWTSecret()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(secret + ":" + purpose), nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEmailVerificationToken_RoundTrip(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+
+	token, err := NewEmailVerificationToken(7, "test@email.com")
+	if err != nil {
+		t.Fatalf("Error creating token: %s", err)
+	}
+	claims, err := ParseEmailVerificationToken(token)
+	if err != nil {
+		t.Fatalf("Error parsing token: %s", err)
+	}
+
+	if claims.UserID != 7 {
+		t.Errorf("Expected user id to be 7, got %d", claims.UserID)
+	}
+	if claims.Email != "test@email.com" {
+		t.Errorf("Expected email to be test@email.com, got %s", claims.Email)
+	}
+}
+
+func TestEmailVerificationToken_IsNotAccessToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+
+	verificationToken, err := NewEmailVerificationToken(7, "test@email.com")
+	if err != nil {
+		t.Fatalf("Error creating token: %s", err)
+	}
+	if _, err := ParseUserToken(verificationToken); err == nil {
+		t.Errorf("Expected verification token to be rejected as user token")
+	}
+
+	accessToken, err := NewAccessToken(&NewTokenProps{ID: 7, Email: "test@email.com", CreatedAt: time.Now(), UpdatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Error creating token: %s", err)
+	}
+	if _, err := ParseEmailVerificationToken(accessToken); err == nil {
+		t.Errorf("Expected access token to be rejected as verification token")
+	}
+}
+
+func TestEmailChangeToken_IsNotVerificationToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+
+	changeToken, err := NewEmailChangeToken(7, "new@email.com", 3)
+	if err != nil {
+		t.Fatalf("Error creating token: %s", err)
+	}
+	if _, err := ParseEmailVerificationToken(changeToken); err == nil {
+		t.Errorf("Expected email change token to be rejected as verification token")
+	}
+	claims, err := ParseEmailChangeToken(changeToken)
+	if err != nil {
+		t.Fatalf("Error parsing token: %s", err)
+	}
+	if claims.Email != "new@email.com" {
+		t.Errorf("Expected email to be new@email.com, got %s", claims.Email)
+	}
+	if claims.SessionVersion != 3 {
+		t.Errorf("Expected session version to be 3, got %d", claims.SessionVersion)
+	}
+}
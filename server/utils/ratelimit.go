@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// entries are only swept once the limiter grows past this size, so small limiters never pay for it
+const rateLimiterSweepSize = 1024
+
+// RateLimiter allows one action per key every interval. Checking and reserving the next slot
+// happens under a single lock, so concurrent callers with the same key can't both be allowed.
+type RateLimiter struct {
+	nextAllowed map[string]time.Time
+	lock        sync.Mutex
+	now         func() time.Time
+}
+
+// Allow reports whether the action for the key can happen now. When it can, the next slot is reserved
+// interval from now, otherwise the remaining time until the action is allowed is returned.
+func (l *RateLimiter) Allow(key string, interval time.Duration) (bool, time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.now()
+	if next, found := l.nextAllowed[key]; found && now.Before(next) {
+		return false, next.Sub(now)
+	}
+	if len(l.nextAllowed) >= rateLimiterSweepSize {
+		l.sweep(now)
+	}
+	l.nextAllowed[key] = now.Add(interval)
+	return true, 0
+}
+
+// Release frees the slot reserved by Allow, used when the limited action failed and shouldn't count.
+func (l *RateLimiter) Release(key string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.nextAllowed, key)
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, next := range l.nextAllowed {
+		if !now.Before(next) {
+			delete(l.nextAllowed, key)
+		}
+	}
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		nextAllowed: make(map[string]time.Time),
+		lock:        sync.Mutex{},
+		now:         time.Now,
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_AllowsOnlyOneConcurrentCaller(t *testing.T) {
+	l := NewRateLimiter()
+	var allowed atomic.Int32
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := l.Allow("1:1", time.Minute); ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 1 {
+		t.Errorf("Expected exactly 1 allowed call, got %d", allowed.Load())
+	}
+}
+
+func TestRateLimiter_ReturnsRetryAfterUntilIntervalPasses(t *testing.T) {
+	now := time.Date(2024, 6, 28, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter()
+	l.now = func() time.Time { return now }
+
+	if ok, _ := l.Allow("1:1", 10*time.Second); !ok {
+		t.Fatalf("Expected first call to be allowed")
+	}
+
+	now = now.Add(4 * time.Second)
+	ok, retryAfter := l.Allow("1:1", 10*time.Second)
+	if ok {
+		t.Errorf("Expected call within interval to be rejected")
+	}
+	if retryAfter != 6*time.Second {
+		t.Errorf("Expected retry after to be %s, got %s", 6*time.Second, retryAfter)
+	}
+
+	if ok, _ := l.Allow("1:2", 10*time.Second); !ok {
+		t.Errorf("Expected other key not to be limited")
+	}
+
+	now = now.Add(6 * time.Second)
+	if ok, _ := l.Allow("1:1", 10*time.Second); !ok {
+		t.Errorf("Expected call after interval to be allowed")
+	}
+}
+
+func TestRateLimiter_ReleaseFreesSlot(t *testing.T) {
+	l := NewRateLimiter()
+
+	if ok, _ := l.Allow("1:1", time.Minute); !ok {
+		t.Fatalf("Expected first call to be allowed")
+	}
+	l.Release("1:1")
+
+	if ok, _ := l.Allow("1:1", time.Minute); !ok {
+		t.Errorf("Expected call after release to be allowed")
+	}
+}
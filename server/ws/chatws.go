@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/kacperhemperek/discord-go/models"
+	"slices"
 	"sync"
 )
 
@@ -14,9 +15,12 @@ var (
 
 type ChatServiceInterface interface {
 	AddChatConn(chatID, userID int, conn *websocket.Conn) string
-	BroadcastNewMessage(chatID int, message *models.MessageWithUser) error
-	BroadcastNewChatName(chatID int, name string) error
+	BroadcastNewMessage(chatID int, message *models.MessageWithUser, blockerIDs []int) error
+	BroadcastChatUpdated(chatID int, changes *models.ChatUpdate) error
 	CloseConn(chatID int, connID string) error
+	CloseChat(chatID int) error
+	CloseUserConns(chatID, userID int) error
+	CloseAllUserConns(userID int) error
 	GetActiveUserIDs(chatID int) ([]int, error)
 }
 
@@ -28,6 +32,7 @@ type ChatConn struct {
 type ChatService struct {
 	chats     map[int]map[string]*ChatConn
 	chatsLock sync.RWMutex
+	userConns *UserConnCounter
 }
 
 func (s *ChatService) AddChatConn(chatID, userID int, conn *websocket.Conn) string {
@@ -35,6 +40,7 @@ func (s *ChatService) AddChatConn(chatID, userID int, conn *websocket.Conn) stri
 	_, chatFound := s.chats[chatID]
 	defer s.chatsLock.Unlock()
 	connID := uuid.New().String()
+	s.userConns.Add(userID)
 	connObj := &ChatConn{
 		UserID: userID,
 		Conn:   conn,
@@ -49,31 +55,73 @@ func (s *ChatService) AddChatConn(chatID, userID int, conn *websocket.Conn) stri
 	return connID
 }
 
-func (s *ChatService) BroadcastNewMessage(chatID int, message *models.MessageWithUser) error {
-	nm := newNewMessage(message)
-	return s.broadcastMessage(chatID, nm)
+// BroadcastNewMessage sends the message to every connection of the chat, users who blocked
+// the sender get it flagged so their clients can collapse it.
+func (s *ChatService) BroadcastNewMessage(chatID int, message *models.MessageWithUser, blockerIDs []int) error {
+	s.chatsLock.Lock()
+	defer s.chatsLock.Unlock()
+	chatConns, chatFound := s.chats[chatID]
+	if !chatFound {
+		return ChatNotFoundErr
+	}
+	conns := make([]*websocket.Conn, 0)
+	blockerConns := make([]*websocket.Conn, 0)
+	for _, connObj := range chatConns {
+		if slices.Contains(blockerIDs, connObj.UserID) {
+			blockerConns = append(blockerConns, connObj.Conn)
+			continue
+		}
+		conns = append(conns, connObj.Conn)
+	}
+	if err := broadcast(newNewMessage(message), conns); err != nil {
+		return err
+	}
+	flagged := *message
+	flagged.FromBlockedUser = true
+	return broadcast(newNewMessage(&flagged), blockerConns)
 }
 
-func (s *ChatService) BroadcastNewChatName(chatID int, name string) error {
-	changeNameMessage := newChatNameChanged(name)
-	return s.broadcastMessage(chatID, changeNameMessage)
+func (s *ChatService) BroadcastChatUpdated(chatID int, changes *models.ChatUpdate) error {
+	chatUpdatedMessage := newChatUpdated(chatID, changes)
+	return s.broadcastMessage(chatID, chatUpdatedMessage)
 }
 
 func (s *ChatService) CloseConn(chatID int, connID string) error {
 	s.chatsLock.Lock()
 	defer s.chatsLock.Unlock()
 	chatConns, chatFound := s.chats[chatID]
-	if chatFound {
-		conn, connFound := chatConns[connID]
-		if connFound {
-			err := conn.Conn.Close()
-			if err != nil {
-				return err
-			}
-			delete(chatConns, connID)
+	if !chatFound {
+		return ChatNotFoundErr
+	}
+	conn, connFound := chatConns[connID]
+	if !connFound {
+		return ChatNotFoundErr
+	}
+	err := conn.Conn.Close()
+	delete(chatConns, connID)
+	s.userConns.Remove(conn.UserID)
+	return err
+}
+
+// CloseChat notifies every connection of the chat that it was deleted and closes them.
+func (s *ChatService) CloseChat(chatID int) error {
+	s.chatsLock.Lock()
+	defer s.chatsLock.Unlock()
+	chatConns, chatFound := s.chats[chatID]
+	if !chatFound {
+		return ChatNotFoundErr
+	}
+	delete(s.chats, chatID)
+	message := newChatDeleted(chatID)
+	var closeErr error
+	for _, connObj := range chatConns {
+		_ = connObj.Conn.WriteJSON(message)
+		if err := connObj.Conn.Close(); err != nil {
+			closeErr = err
 		}
+		s.userConns.Remove(connObj.UserID)
 	}
-	return ChatNotFoundErr
+	return closeErr
 }
 
 func (s *ChatService) broadcastMessage(chatID int, message any) error {
@@ -91,6 +139,54 @@ func (s *ChatService) broadcastMessage(chatID int, message any) error {
 	return broadcast(message, conns)
 }
 
+// CloseUserConns closes every connection the user has opened to the chat, used when the user is removed from it.
+func (s *ChatService) CloseUserConns(chatID, userID int) error {
+	s.chatsLock.Lock()
+	defer s.chatsLock.Unlock()
+	chatConns, chatFound := s.chats[chatID]
+	if !chatFound {
+		return nil
+	}
+	var closeErr error
+	for connID, connObj := range chatConns {
+		if connObj.UserID != userID {
+			continue
+		}
+		delete(chatConns, connID)
+		if err := connObj.Conn.Close(); err != nil {
+			closeErr = err
+		}
+		s.userConns.Remove(userID)
+	}
+	if len(chatConns) == 0 {
+		delete(s.chats, chatID)
+	}
+	return closeErr
+}
+
+// CloseAllUserConns closes the user's connections to every chat, used when the account is deleted.
+func (s *ChatService) CloseAllUserConns(userID int) error {
+	s.chatsLock.Lock()
+	defer s.chatsLock.Unlock()
+	var closeErr error
+	for chatID, chatConns := range s.chats {
+		for connID, connObj := range chatConns {
+			if connObj.UserID != userID {
+				continue
+			}
+			delete(chatConns, connID)
+			if err := connObj.Conn.Close(); err != nil {
+				closeErr = err
+			}
+			s.userConns.Remove(userID)
+		}
+		if len(chatConns) == 0 {
+			delete(s.chats, chatID)
+		}
+	}
+	return closeErr
+}
+
 func (s *ChatService) GetActiveUserIDs(chatID int) ([]int, error) {
 	s.chatsLock.Lock()
 	defer s.chatsLock.Unlock()
@@ -107,10 +203,11 @@ func (s *ChatService) GetActiveUserIDs(chatID int) ([]int, error) {
 	return memberIDs, nil
 }
 
-func NewChatService() *ChatService {
+func NewChatService(userConns *UserConnCounter) *ChatService {
 	return &ChatService{
 		chats:     make(map[int]map[string]*ChatConn),
 		chatsLock: sync.RWMutex{},
+		userConns: userConns,
 	}
 }
 
@@ -121,16 +218,30 @@ func newNewMessage(m *models.MessageWithUser) *newMessage {
 	}
 }
 
-func newChatNameChanged(name string) *chatNameChanged {
-	return &chatNameChanged{
-		Type:    ChatNameUpdated,
-		NewName: name,
+func newChatUpdated(chatID int, changes *models.ChatUpdate) *chatUpdated {
+	return &chatUpdated{
+		Type:    ChatUpdated,
+		ChatID:  chatID,
+		Changes: changes,
+	}
+}
+
+func newChatDeleted(chatID int) *chatDeleted {
+	return &chatDeleted{
+		Type:   ChatDeleted,
+		ChatID: chatID,
 	}
 }
 
-type chatNameChanged struct {
-	Type    string `json:"type"`
-	NewName string `json:"newName"`
+type chatDeleted struct {
+	Type   string `json:"type"`
+	ChatID int    `json:"chatId"`
+}
+
+type chatUpdated struct {
+	Type    string             `json:"type"`
+	ChatID  int                `json:"chatId"`
+	Changes *models.ChatUpdate `json:"changes"`
 }
 
 type newMessage struct {
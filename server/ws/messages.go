@@ -2,4 +2,20 @@ package ws
 
 const UpdateAccessToken = "UPDATE_ACCESS_TOKEN"
 const NewMessage = "NEW_MESSAGE"
-const ChatNameUpdated = "CHAT_NAME_UPDATED"
+const ChatUpdated = "CHAT_UPDATED"
+const ChatDeleted = "CHAT_DELETED"
+const FriendRequestCancelled = "FRIEND_REQUEST_CANCELLED"
+const PresenceUpdate = "PRESENCE_UPDATE"
+const CustomStatusUpdate = "CUSTOM_STATUS_UPDATE"
+const UserUpdated = "USER_UPDATED"
+const SetPresence = "SET_PRESENCE"
+
+const VoiceOffer = "VOICE_OFFER"
+const VoiceAnswer = "VOICE_ANSWER"
+const VoiceIceCandidate = "VOICE_ICE_CANDIDATE"
+const VoiceState = "VOICE_STATE"
+const VoiceParticipants = "VOICE_PARTICIPANTS"
+const VoiceParticipantJoined = "VOICE_PARTICIPANT_JOINED"
+const VoiceParticipantLeft = "VOICE_PARTICIPANT_LEFT"
+const VoiceStateUpdated = "VOICE_STATE_UPDATED"
+const VoiceError = "VOICE_ERROR"
@@ -14,18 +14,22 @@ var (
 type NotificationService struct {
 	conns     map[int]map[string]*websocket.Conn
 	connsLock sync.RWMutex
+	userConns *UserConnCounter
 }
 
 type NotificationServiceInterface interface {
 	AddConn(userID int, conn *websocket.Conn) string
 	RemoveConn(userID int, connID string) error
+	CloseUserConns(userID int) error
 	SendNotification(userID int, n any) error
+	SendFriendRequestCancelled(userID, friendshipID int, notificationIDs []int) error
 }
 
 func (s *NotificationService) AddConn(userID int, conn *websocket.Conn) string {
 	s.connsLock.Lock()
 	defer s.connsLock.Unlock()
 	connID := uuid.New().String()
+	s.userConns.Add(userID)
 	if userConnectionMap, userConnectionsExist := s.conns[userID]; userConnectionsExist {
 		userConnectionMap[connID] = conn
 	} else {
@@ -45,27 +49,61 @@ func (s *NotificationService) RemoveConn(userID int, connID string) error {
 		if len(s.conns[userID]) == 0 {
 			delete(s.conns, userID)
 		}
+		s.userConns.Remove(userID)
 		return err
 	}
 	return NoUserConns
 }
 
+// CloseUserConns closes every notification socket of the user, used when the account is deleted.
+func (s *NotificationService) CloseUserConns(userID int) error {
+	s.connsLock.Lock()
+	defer s.connsLock.Unlock()
+	var closeErr error
+	for _, conn := range s.conns[userID] {
+		if err := conn.Close(); err != nil {
+			closeErr = err
+		}
+		s.userConns.Remove(userID)
+	}
+	delete(s.conns, userID)
+	return closeErr
+}
+
 func (s *NotificationService) SendNotification(userID int, n any) error {
 	s.connsLock.Lock()
 	defer s.connsLock.Unlock()
 	if conns, userConnsFound := s.conns[userID]; userConnsFound {
+		var writeErr error
 		for _, conn := range conns {
-			err := conn.WriteJSON(n)
-			return err
+			if err := conn.WriteJSON(n); err != nil {
+				writeErr = err
+			}
 		}
-		return nil
+		return writeErr
 	}
 	return NoUserConns
 }
 
-func NewNotificationService() *NotificationService {
+// SendFriendRequestCancelled lets the recipient of a withdrawn friend request drop it and its notifications.
+func (s *NotificationService) SendFriendRequestCancelled(userID, friendshipID int, notificationIDs []int) error {
+	return s.SendNotification(userID, &friendRequestCancelled{
+		Type:            FriendRequestCancelled,
+		FriendshipID:    friendshipID,
+		NotificationIDs: notificationIDs,
+	})
+}
+
+func NewNotificationService(userConns *UserConnCounter) *NotificationService {
 	return &NotificationService{
 		conns:     make(map[int]map[string]*websocket.Conn),
 		connsLock: sync.RWMutex{},
+		userConns: userConns,
 	}
 }
+
+type friendRequestCancelled struct {
+	Type            string `json:"type"`
+	FriendshipID    int    `json:"friendshipId"`
+	NotificationIDs []int  `json:"notificationIds"`
+}
@@ -0,0 +1,220 @@
+package ws
+
+import (
+	"github.com/kacperhemperek/discord-go/models"
+	"github.com/kacperhemperek/discord-go/types"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// presenceOfflineGracePeriod is how long a user stays online after their last socket closes,
+// so page reloads and short network drops don't show them going offline.
+const presenceOfflineGracePeriod = 15 * time.Second
+
+type PresenceServiceInterface interface {
+	Connect(userID int, connID string)
+	Disconnect(userID int, connID string)
+	SetIdle(userID int, connID string, idle bool)
+	GetStatus(userID int) types.PresenceStatus
+	BroadcastCustomStatus(userID int, status *models.CustomStatus)
+	BroadcastUserUpdated(user *models.PublicUser)
+}
+
+// PresenceSender delivers presence updates, NotificationService is used in the app.
+type PresenceSender interface {
+	SendNotification(userID int, n any) error
+}
+
+// PresenceAudience returns ids of users who should be told about the user's presence changes.
+type PresenceAudience func(userID int) ([]int, error)
+
+// PresenceService derives user presence from their notification sockets. A user is online while any of their
+// sockets is open and active, idle when every socket reported being idle and offline once the last socket
+// has been closed for longer than the grace period.
+type PresenceService struct {
+	// conns holds whether each of the user's sockets is idle
+	conns         map[int]map[string]bool
+	statuses      map[int]types.PresenceStatus
+	offlineTimers map[int]*time.Timer
+	gracePeriod   time.Duration
+	lock          sync.Mutex
+	sender        PresenceSender
+	audience      PresenceAudience
+}
+
+func (s *PresenceService) Connect(userID int, connID string) {
+	s.lock.Lock()
+	if timer, found := s.offlineTimers[userID]; found {
+		timer.Stop()
+		delete(s.offlineTimers, userID)
+	}
+	if _, found := s.conns[userID]; !found {
+		s.conns[userID] = make(map[string]bool)
+	}
+	s.conns[userID][connID] = false
+	changed := s.updateStatus(userID)
+	s.lock.Unlock()
+	s.publish(userID, changed)
+}
+
+func (s *PresenceService) Disconnect(userID int, connID string) {
+	s.lock.Lock()
+	delete(s.conns[userID], connID)
+	if len(s.conns[userID]) != 0 {
+		changed := s.updateStatus(userID)
+		s.lock.Unlock()
+		s.publish(userID, changed)
+		return
+	}
+	delete(s.conns, userID)
+	if _, found := s.offlineTimers[userID]; !found {
+		s.offlineTimers[userID] = time.AfterFunc(s.gracePeriod, func() {
+			s.expire(userID)
+		})
+	}
+	s.lock.Unlock()
+}
+
+func (s *PresenceService) SetIdle(userID int, connID string, idle bool) {
+	s.lock.Lock()
+	if _, found := s.conns[userID][connID]; !found {
+		s.lock.Unlock()
+		return
+	}
+	s.conns[userID][connID] = idle
+	changed := s.updateStatus(userID)
+	s.lock.Unlock()
+	s.publish(userID, changed)
+}
+
+func (s *PresenceService) GetStatus(userID int) types.PresenceStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.statuses[userID]
+}
+
+// BroadcastCustomStatus sends the user's new custom status, nil when it was cleared, to the presence audience
+// and to the user's own sockets so their other clients pick it up.
+func (s *PresenceService) BroadcastCustomStatus(userID int, status *models.CustomStatus) {
+	audience, err := s.audience(userID)
+	if err != nil {
+		slog.Error("could not get presence audience", "userID", userID, "error", err)
+		return
+	}
+	update := &customStatusUpdate{
+		Type:         CustomStatusUpdate,
+		UserID:       userID,
+		CustomStatus: status,
+	}
+	for _, recipientID := range append(audience, userID) {
+		_ = s.sender.SendNotification(recipientID, update)
+	}
+}
+
+// BroadcastUserUpdated sends the user's new public profile to the presence audience and to the user's own sockets.
+func (s *PresenceService) BroadcastUserUpdated(user *models.PublicUser) {
+	audience, err := s.audience(user.ID)
+	if err != nil {
+		slog.Error("could not get presence audience", "userID", user.ID, "error", err)
+		return
+	}
+	update := &userUpdated{
+		Type: UserUpdated,
+		User: user,
+	}
+	for _, recipientID := range append(audience, user.ID) {
+		_ = s.sender.SendNotification(recipientID, update)
+	}
+}
+
+// expire marks the user offline once the grace period after their last socket closed has passed.
+func (s *PresenceService) expire(userID int) {
+	s.lock.Lock()
+	delete(s.offlineTimers, userID)
+	if _, reconnected := s.conns[userID]; reconnected {
+		s.lock.Unlock()
+		return
+	}
+	changed := s.updateStatus(userID)
+	s.lock.Unlock()
+	s.publish(userID, changed)
+}
+
+// updateStatus recomputes the user's status from their sockets and returns the new one when it changed,
+// it has to be called with the lock held.
+func (s *PresenceService) updateStatus(userID int) *types.PresenceStatus {
+	status := types.OfflinePresenceStatus
+	if conns, found := s.conns[userID]; found {
+		status = types.IdlePresenceStatus
+		for _, idle := range conns {
+			if !idle {
+				status = types.OnlinePresenceStatus
+				break
+			}
+		}
+	}
+	previous := s.statuses[userID]
+	if previous.Is(status) {
+		return nil
+	}
+	if status.Is(types.OfflinePresenceStatus) {
+		delete(s.statuses, userID)
+	} else {
+		s.statuses[userID] = status
+	}
+	return &status
+}
+
+func (s *PresenceService) publish(userID int, status *types.PresenceStatus) {
+	if status == nil {
+		return
+	}
+	audience, err := s.audience(userID)
+	if err != nil {
+		slog.Error("could not get presence audience", "userID", userID, "error", err)
+		return
+	}
+	update := newPresenceUpdate(userID, *status)
+	for _, recipientID := range audience {
+		// most of the audience is usually offline
+		_ = s.sender.SendNotification(recipientID, update)
+	}
+}
+
+func NewPresenceService(sender PresenceSender, audience PresenceAudience) *PresenceService {
+	return &PresenceService{
+		conns:         make(map[int]map[string]bool),
+		statuses:      make(map[int]types.PresenceStatus),
+		offlineTimers: make(map[int]*time.Timer),
+		gracePeriod:   presenceOfflineGracePeriod,
+		lock:          sync.Mutex{},
+		sender:        sender,
+		audience:      audience,
+	}
+}
+
+func newPresenceUpdate(userID int, status types.PresenceStatus) *presenceUpdate {
+	return &presenceUpdate{
+		Type:   PresenceUpdate,
+		UserID: userID,
+		Status: status,
+	}
+}
+
+type presenceUpdate struct {
+	Type   string               `json:"type"`
+	UserID int                  `json:"userId"`
+	Status types.PresenceStatus `json:"status"`
+}
+
+type customStatusUpdate struct {
+	Type         string               `json:"type"`
+	UserID       int                  `json:"userId"`
+	CustomStatus *models.CustomStatus `json:"customStatus"`
+}
+
+type userUpdated struct {
+	Type string             `json:"type"`
+	User *models.PublicUser `json:"user"`
+}
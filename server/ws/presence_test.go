@@ -0,0 +1,97 @@
+package ws
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakePresenceSender struct {
+	lock    sync.Mutex
+	updates []*presenceUpdate
+}
+
+func (s *fakePresenceSender) SendNotification(userID int, n any) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.updates = append(s.updates, n.(*presenceUpdate))
+	return nil
+}
+
+func (s *fakePresenceSender) statuses() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	statuses := make([]string, len(s.updates))
+	for i, u := range s.updates {
+		statuses[i] = u.Status.String()
+	}
+	return statuses
+}
+
+func newTestPresenceService(gracePeriod time.Duration) (*PresenceService, *fakePresenceSender) {
+	sender := &fakePresenceSender{}
+	s := NewPresenceService(sender, func(userID int) ([]int, error) {
+		return []int{2}, nil
+	})
+	s.gracePeriod = gracePeriod
+	return s, sender
+}
+
+func TestPresenceService_OnlineOnlyOnFirstConnection(t *testing.T) {
+	s, sender := newTestPresenceService(time.Hour)
+	s.Connect(1, "a")
+	s.Connect(1, "b")
+	s.Disconnect(1, "a")
+
+	if got := sender.statuses(); len(got) != 1 || got[0] != "online" {
+		t.Errorf("Expected a single online update, got %v", got)
+	}
+}
+
+func TestPresenceService_OfflineAfterGracePeriod(t *testing.T) {
+	s, sender := newTestPresenceService(10 * time.Millisecond)
+	s.Connect(1, "a")
+	s.Disconnect(1, "a")
+
+	status := s.GetStatus(1)
+	if status.String() != "online" {
+		t.Errorf("Expected user to stay online during the grace period, got %s", status.String())
+	}
+	time.Sleep(100 * time.Millisecond)
+	status = s.GetStatus(1)
+	if status.String() != "offline" {
+		t.Errorf("Expected user to be offline after the grace period, got %s", status.String())
+	}
+	if got := sender.statuses(); len(got) != 2 || got[1] != "offline" {
+		t.Errorf("Expected online and offline updates, got %v", got)
+	}
+}
+
+func TestPresenceService_ReconnectWithinGracePeriod(t *testing.T) {
+	s, sender := newTestPresenceService(20 * time.Millisecond)
+	s.Connect(1, "a")
+	s.Disconnect(1, "a")
+	s.Connect(1, "b")
+	time.Sleep(100 * time.Millisecond)
+
+	if got := sender.statuses(); len(got) != 1 {
+		t.Errorf("Expected reconnecting to not send updates, got %v", got)
+	}
+}
+
+func TestPresenceService_IdleWhenEveryConnectionIsIdle(t *testing.T) {
+	s, sender := newTestPresenceService(time.Hour)
+	s.Connect(1, "a")
+	s.Connect(1, "b")
+	s.SetIdle(1, "a", true)
+	status := s.GetStatus(1)
+	if status.String() != "online" {
+		t.Errorf("Expected user with an active connection to be online, got %s", status.String())
+	}
+	s.SetIdle(1, "b", true)
+	s.SetIdle(1, "a", false)
+
+	if got := sender.statuses(); len(got) != 3 || got[1] != "idle" || got[2] != "online" {
+		t.Errorf("Expected online, idle, online updates, got %v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package ws
+
+import "sync"
+
+// LastSeenRecorder is told when a user's last socket closes, store.LastSeenTracker is used in the app.
+type LastSeenRecorder interface {
+	RecordLastSeen(userID int)
+}
+
+// UserConnCounter counts the sockets each user has open across the chat and notification services,
+// the user's last seen is recorded once their last socket of any kind is gone.
+type UserConnCounter struct {
+	counts   map[int]int
+	lock     sync.Mutex
+	lastSeen LastSeenRecorder
+}
+
+func (c *UserConnCounter) Add(userID int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.counts[userID]++
+}
+
+// Remove has to be called once for every socket passed to Add, whether the client or the server closed it.
+func (c *UserConnCounter) Remove(userID int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	count, found := c.counts[userID]
+	if !found {
+		return
+	}
+	if count > 1 {
+		c.counts[userID] = count - 1
+		return
+	}
+	delete(c.counts, userID)
+	c.lastSeen.RecordLastSeen(userID)
+}
+
+func NewUserConnCounter(lastSeen LastSeenRecorder) *UserConnCounter {
+	return &UserConnCounter{
+		counts:   make(map[int]int),
+		lock:     sync.Mutex{},
+		lastSeen: lastSeen,
+	}
+}
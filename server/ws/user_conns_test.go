@@ -0,0 +1,33 @@
+package ws
+
+import "testing"
+
+type fakeLastSeenRecorder struct {
+	recorded []int
+}
+
+func (r *fakeLastSeenRecorder) RecordLastSeen(userID int) {
+	r.recorded = append(r.recorded, userID)
+}
+
+func TestUserConnCounter_RecordsLastSeenOnlyWhenLastConnCloses(t *testing.T) {
+	recorder := &fakeLastSeenRecorder{}
+	c := NewUserConnCounter(recorder)
+	c.Add(1)
+	c.Add(1)
+
+	c.Remove(1)
+	if len(recorder.recorded) != 0 {
+		t.Errorf("Expected last seen not to be recorded while a socket is open, got %v", recorder.recorded)
+	}
+
+	c.Remove(1)
+	if len(recorder.recorded) != 1 || recorder.recorded[0] != 1 {
+		t.Errorf("Expected last seen of user 1 to be recorded once, got %v", recorder.recorded)
+	}
+
+	c.Remove(1)
+	if len(recorder.recorded) != 1 {
+		t.Errorf("Expected extra removes to be ignored, got %v", recorder.recorded)
+	}
+}
@@ -0,0 +1,268 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"log/slog"
+	"sync"
+)
+
+var (
+	VoiceRoomNotFoundErr    = errors.New("voice room not found")
+	VoicePeerNotFoundErr    = errors.New("voice peer not found")
+	InvalidVoiceMessageErr  = errors.New("invalid voice signaling message")
+	UnsupportedVoiceTypeErr = errors.New("unsupported voice signaling message type")
+)
+
+// VoiceConn is the part of a websocket connection the voice relay needs, it lets tests
+// drive the relay with scripted peers instead of real sockets.
+type VoiceConn interface {
+	WriteJSON(v any) error
+	Close() error
+}
+
+type VoiceServiceInterface interface {
+	Join(chatID, userID int, conn VoiceConn) (string, error)
+	Leave(chatID int, peerID string) error
+	HandleMessage(chatID int, peerID string, msg []byte) error
+	GetParticipants(chatID int) []*VoiceParticipant
+	CloseRoom(chatID int)
+	RemoveUser(chatID, userID int)
+}
+
+type VoiceParticipant struct {
+	PeerID   string `json:"peerId"`
+	UserID   int    `json:"userId"`
+	Muted    bool   `json:"muted"`
+	Deafened bool   `json:"deafened"`
+	conn     VoiceConn
+}
+
+// VoiceService keeps voice room participants per chat and relays WebRTC signaling between them,
+// media itself never goes through the server.
+type VoiceService struct {
+	rooms     map[int]map[string]*VoiceParticipant
+	roomsLock sync.Mutex
+}
+
+func (s *VoiceService) Join(chatID, userID int, conn VoiceConn) (string, error) {
+	s.roomsLock.Lock()
+	defer s.roomsLock.Unlock()
+	room, roomFound := s.rooms[chatID]
+	if !roomFound {
+		room = make(map[string]*VoiceParticipant)
+		s.rooms[chatID] = room
+	}
+	participant := &VoiceParticipant{
+		PeerID: uuid.New().String(),
+		UserID: userID,
+		conn:   conn,
+	}
+
+	others := make([]*VoiceParticipant, 0, len(room))
+	for _, p := range room {
+		others = append(others, p)
+	}
+	room[participant.PeerID] = participant
+
+	err := conn.WriteJSON(&voiceParticipantsMessage{
+		Type:         VoiceParticipants,
+		PeerID:       participant.PeerID,
+		Participants: others,
+	})
+	if err != nil {
+		delete(room, participant.PeerID)
+		if len(room) == 0 {
+			delete(s.rooms, chatID)
+		}
+		return "", err
+	}
+	s.broadcastToRoom(room, participant.PeerID, &voiceParticipantMessage{
+		Type:        VoiceParticipantJoined,
+		Participant: participant,
+	})
+	return participant.PeerID, nil
+}
+
+func (s *VoiceService) Leave(chatID int, peerID string) error {
+	s.roomsLock.Lock()
+	defer s.roomsLock.Unlock()
+	room, roomFound := s.rooms[chatID]
+	if !roomFound {
+		return VoiceRoomNotFoundErr
+	}
+	participant, peerFound := room[peerID]
+	if !peerFound {
+		return VoicePeerNotFoundErr
+	}
+	delete(room, peerID)
+	if len(room) == 0 {
+		delete(s.rooms, chatID)
+	}
+	s.broadcastToRoom(room, peerID, &voiceParticipantMessage{
+		Type:        VoiceParticipantLeft,
+		Participant: participant,
+	})
+	return participant.conn.Close()
+}
+
+// HandleMessage processes a single signaling message sent by peerID. Offers, answers and ICE candidates
+// are forwarded as-is to the peer they are addressed to, state changes are broadcast to the whole room.
+func (s *VoiceService) HandleMessage(chatID int, peerID string, msg []byte) error {
+	in := &voiceIncomingMessage{}
+	if err := json.Unmarshal(msg, in); err != nil {
+		return InvalidVoiceMessageErr
+	}
+
+	s.roomsLock.Lock()
+	defer s.roomsLock.Unlock()
+	room, roomFound := s.rooms[chatID]
+	if !roomFound {
+		return VoiceRoomNotFoundErr
+	}
+	sender, peerFound := room[peerID]
+	if !peerFound {
+		return VoicePeerNotFoundErr
+	}
+
+	switch in.Type {
+	case VoiceOffer, VoiceAnswer, VoiceIceCandidate:
+		if len(in.Payload) == 0 || in.To == "" {
+			return s.replyError(sender, InvalidVoiceMessageErr)
+		}
+		// peers can only signal to participants of the same room, which keeps relaying scoped to the chat
+		target, targetFound := room[in.To]
+		if !targetFound || target.PeerID == sender.PeerID {
+			return s.replyError(sender, VoicePeerNotFoundErr)
+		}
+		return target.conn.WriteJSON(&voiceRelayMessage{
+			Type:    in.Type,
+			From:    sender.PeerID,
+			UserID:  sender.UserID,
+			Payload: in.Payload,
+		})
+	case VoiceState:
+		if in.Muted != nil {
+			sender.Muted = *in.Muted
+		}
+		if in.Deafened != nil {
+			sender.Deafened = *in.Deafened
+		}
+		// a deafened user can't hear anyone, so they stay muted until they undeafen
+		if sender.Deafened {
+			sender.Muted = true
+		}
+		s.broadcastToRoom(room, "", &voiceParticipantMessage{
+			Type:        VoiceStateUpdated,
+			Participant: sender,
+		})
+		return nil
+	default:
+		return s.replyError(sender, UnsupportedVoiceTypeErr)
+	}
+}
+
+func (s *VoiceService) GetParticipants(chatID int) []*VoiceParticipant {
+	s.roomsLock.Lock()
+	defer s.roomsLock.Unlock()
+	participants := make([]*VoiceParticipant, 0)
+	for _, p := range s.rooms[chatID] {
+		copied := *p
+		participants = append(participants, &copied)
+	}
+	return participants
+}
+
+// CloseRoom disconnects every participant of the chat's voice room.
+func (s *VoiceService) CloseRoom(chatID int) {
+	s.roomsLock.Lock()
+	defer s.roomsLock.Unlock()
+	for _, p := range s.rooms[chatID] {
+		if err := p.conn.Close(); err != nil {
+			slog.Error("could not close voice connection", "peerID", p.PeerID, "error", err)
+		}
+	}
+	delete(s.rooms, chatID)
+}
+
+// RemoveUser disconnects every peer the user has in the room and lets the rest of the room know they left.
+func (s *VoiceService) RemoveUser(chatID, userID int) {
+	s.roomsLock.Lock()
+	defer s.roomsLock.Unlock()
+	room := s.rooms[chatID]
+	for peerID, p := range room {
+		if p.UserID != userID {
+			continue
+		}
+		delete(room, peerID)
+		s.broadcastToRoom(room, peerID, &voiceParticipantMessage{
+			Type:        VoiceParticipantLeft,
+			Participant: p,
+		})
+		if err := p.conn.Close(); err != nil {
+			slog.Error("could not close voice connection", "peerID", p.PeerID, "error", err)
+		}
+	}
+	if len(room) == 0 {
+		delete(s.rooms, chatID)
+	}
+}
+
+// broadcastToRoom sends the message to every participant except the one with excludedPeerID,
+// it has to be called with roomsLock held.
+func (s *VoiceService) broadcastToRoom(room map[string]*VoiceParticipant, excludedPeerID string, message any) {
+	for _, p := range room {
+		if p.PeerID == excludedPeerID {
+			continue
+		}
+		if err := p.conn.WriteJSON(message); err != nil {
+			slog.Error("could not send voice message", "peerID", p.PeerID, "error", err)
+		}
+	}
+}
+
+func (s *VoiceService) replyError(p *VoiceParticipant, err error) error {
+	return p.conn.WriteJSON(&voiceErrorMessage{
+		Type:    VoiceError,
+		Message: err.Error(),
+	})
+}
+
+func NewVoiceService() *VoiceService {
+	return &VoiceService{
+		rooms:     make(map[int]map[string]*VoiceParticipant),
+		roomsLock: sync.Mutex{},
+	}
+}
+
+type voiceIncomingMessage struct {
+	Type     string          `json:"type"`
+	To       string          `json:"to"`
+	Payload  json.RawMessage `json:"payload"`
+	Muted    *bool           `json:"muted"`
+	Deafened *bool           `json:"deafened"`
+}
+
+type voiceRelayMessage struct {
+	Type    string          `json:"type"`
+	From    string          `json:"from"`
+	UserID  int             `json:"userId"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type voiceParticipantsMessage struct {
+	Type         string              `json:"type"`
+	PeerID       string              `json:"peerId"`
+	Participants []*VoiceParticipant `json:"participants"`
+}
+
+type voiceParticipantMessage struct {
+	Type        string            `json:"type"`
+	Participant *VoiceParticipant `json:"participant"`
+}
+
+type voiceErrorMessage struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,219 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type fakePeer struct {
+	lock     sync.Mutex
+	messages []map[string]any
+	closed   bool
+}
+
+func (p *fakePeer) WriteJSON(v any) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	msg := make(map[string]any)
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func (p *fakePeer) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *fakePeer) last() map[string]any {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.messages) == 0 {
+		return nil
+	}
+	return p.messages[len(p.messages)-1]
+}
+
+func (p *fakePeer) count() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.messages)
+}
+
+func joinPeer(t *testing.T, s *VoiceService, chatID, userID int) (*fakePeer, string) {
+	t.Helper()
+	peer := &fakePeer{}
+	peerID, err := s.Join(chatID, userID, peer)
+	if err != nil {
+		t.Fatalf("Error joining voice room: %s", err)
+	}
+	return peer, peerID
+}
+
+func TestVoiceService_JoinAnnouncesParticipants(t *testing.T) {
+	s := NewVoiceService()
+	alice, aliceID := joinPeer(t, s, 1, 10)
+	bob, bobID := joinPeer(t, s, 1, 20)
+
+	if msg := bob.last(); msg["type"] != VoiceParticipants || msg["peerId"] != bobID {
+		t.Errorf("Expected bob to receive participant list with his peer id, got %v", msg)
+	}
+	participants := bob.last()["participants"].([]any)
+	if len(participants) != 1 || participants[0].(map[string]any)["peerId"] != aliceID {
+		t.Errorf("Expected participant list to contain only alice, got %v", participants)
+	}
+
+	msg := alice.last()
+	if msg["type"] != VoiceParticipantJoined {
+		t.Errorf("Expected alice to be notified about bob joining, got %v", msg)
+	}
+	if p := msg["participant"].(map[string]any); p["peerId"] != bobID || p["userId"] != float64(20) {
+		t.Errorf("Expected joined participant to be bob, got %v", p)
+	}
+}
+
+func TestVoiceService_RelaysSignalingOnlyToTarget(t *testing.T) {
+	s := NewVoiceService()
+	alice, aliceID := joinPeer(t, s, 1, 10)
+	bob, bobID := joinPeer(t, s, 1, 20)
+	carol, _ := joinPeer(t, s, 1, 30)
+
+	carolMessages := carol.count()
+	for _, mType := range []string{VoiceOffer, VoiceAnswer, VoiceIceCandidate} {
+		msg := fmt.Sprintf(`{"type":"%s","to":"%s","payload":{"sdp":"v=0"}}`, mType, bobID)
+		if err := s.HandleMessage(1, aliceID, []byte(msg)); err != nil {
+			t.Fatalf("Error handling %s: %s", mType, err)
+		}
+		relayed := bob.last()
+		if relayed["type"] != mType || relayed["from"] != aliceID {
+			t.Errorf("Expected bob to receive %s from alice, got %v", mType, relayed)
+		}
+		if payload := relayed["payload"].(map[string]any); payload["sdp"] != "v=0" {
+			t.Errorf("Expected payload to be relayed unchanged, got %v", payload)
+		}
+	}
+	if carol.count() != carolMessages {
+		t.Errorf("Expected carol not to receive signaling addressed to bob")
+	}
+	if alice.last()["type"] == VoiceError {
+		t.Errorf("Expected alice not to receive an error, got %v", alice.last())
+	}
+}
+
+func TestVoiceService_DoesNotRelayAcrossRooms(t *testing.T) {
+	s := NewVoiceService()
+	alice, aliceID := joinPeer(t, s, 1, 10)
+	other, otherID := joinPeer(t, s, 2, 20)
+
+	otherMessages := other.count()
+	msg := fmt.Sprintf(`{"type":"%s","to":"%s","payload":{"candidate":"c"}}`, VoiceIceCandidate, otherID)
+	if err := s.HandleMessage(1, aliceID, []byte(msg)); err != nil {
+		t.Fatalf("Error handling message: %s", err)
+	}
+	if other.count() != otherMessages {
+		t.Errorf("Expected peer in another room not to receive the candidate")
+	}
+	if reply := alice.last(); reply["type"] != VoiceError {
+		t.Errorf("Expected alice to receive an error, got %v", reply)
+	}
+}
+
+func TestVoiceService_StateUpdatesAreBroadcast(t *testing.T) {
+	s := NewVoiceService()
+	alice, aliceID := joinPeer(t, s, 1, 10)
+	bob, _ := joinPeer(t, s, 1, 20)
+
+	if err := s.HandleMessage(1, aliceID, []byte(`{"type":"VOICE_STATE","deafened":true}`)); err != nil {
+		t.Fatalf("Error handling state message: %s", err)
+	}
+	for name, peer := range map[string]*fakePeer{"alice": alice, "bob": bob} {
+		msg := peer.last()
+		if msg["type"] != VoiceStateUpdated {
+			t.Errorf("Expected %s to receive state update, got %v", name, msg)
+			continue
+		}
+		p := msg["participant"].(map[string]any)
+		if p["deafened"] != true || p["muted"] != true {
+			t.Errorf("Expected alice to be deafened and muted, got %v", p)
+		}
+	}
+
+	participants := s.GetParticipants(1)
+	for _, p := range participants {
+		if p.PeerID == aliceID && (!p.Muted || !p.Deafened) {
+			t.Errorf("Expected stored alice state to be muted and deafened, got %+v", p)
+		}
+	}
+}
+
+func TestVoiceService_DeafenedPeerCannotUnmute(t *testing.T) {
+	s := NewVoiceService()
+	_, aliceID := joinPeer(t, s, 1, 10)
+	bob, _ := joinPeer(t, s, 1, 20)
+
+	if err := s.HandleMessage(1, aliceID, []byte(`{"type":"VOICE_STATE","deafened":true}`)); err != nil {
+		t.Fatalf("Error handling state message: %s", err)
+	}
+	if err := s.HandleMessage(1, aliceID, []byte(`{"type":"VOICE_STATE","muted":false}`)); err != nil {
+		t.Fatalf("Error handling state message: %s", err)
+	}
+
+	if p := bob.last()["participant"].(map[string]any); p["deafened"] != true || p["muted"] != true {
+		t.Errorf("Expected deafened alice to stay muted, got %v", p)
+	}
+}
+
+func TestVoiceService_LeaveNotifiesRemainingPeers(t *testing.T) {
+	s := NewVoiceService()
+	alice, aliceID := joinPeer(t, s, 1, 10)
+	bob, _ := joinPeer(t, s, 1, 20)
+
+	if err := s.Leave(1, aliceID); err != nil {
+		t.Fatalf("Error leaving voice room: %s", err)
+	}
+	if !alice.closed {
+		t.Errorf("Expected alice connection to be closed")
+	}
+	if msg := bob.last(); msg["type"] != VoiceParticipantLeft {
+		t.Errorf("Expected bob to be notified about alice leaving, got %v", msg)
+	}
+	if participants := s.GetParticipants(1); len(participants) != 1 {
+		t.Errorf("Expected 1 participant left, got %d", len(participants))
+	}
+	if err := s.HandleMessage(1, aliceID, []byte(`{"type":"VOICE_STATE","muted":true}`)); err != VoicePeerNotFoundErr {
+		t.Errorf("Expected peer not found error after leaving, got %v", err)
+	}
+}
+
+func TestVoiceService_RemoveUserDisconnectsAllOfTheirPeers(t *testing.T) {
+	s := NewVoiceService()
+	alice, _ := joinPeer(t, s, 1, 10)
+	aliceSecondTab, _ := joinPeer(t, s, 1, 10)
+	bob, _ := joinPeer(t, s, 1, 20)
+
+	s.RemoveUser(1, 10)
+
+	if !alice.closed || !aliceSecondTab.closed {
+		t.Errorf("Expected every alice connection to be closed")
+	}
+	if bob.closed {
+		t.Errorf("Expected bob connection to stay open")
+	}
+	if msg := bob.last(); msg["type"] != VoiceParticipantLeft {
+		t.Errorf("Expected bob to be notified about alice leaving, got %v", msg)
+	}
+	participants := s.GetParticipants(1)
+	if len(participants) != 1 || participants[0].UserID != 20 {
+		t.Errorf("Expected only bob to be left in the room, got %+v", participants)
+	}
+}